@@ -12,22 +12,37 @@ import (
 const (
 	// NoopAlert defines a noop alert plugin used for testing.
 	NoopAlert = "noop"
+
+	// WebhookAlert defines an alert plugin that POSTs alerting events to an arbitrary http endpoint.
+	WebhookAlert = "webhook"
 )
 
 // Alert is the interface that plugins must adhere to for operation as an alert plugin.
 type Alert interface {
-	// Emit should send the event to the configured backend alert sink.
-	Emit(event *common.Event)
+	// Emit should send the event to the configured backend alert sink, along with the arbitrary parameters supplied by the filter such as severity, summary, runbook links, or routing hints, the params may be nil.
+	Emit(event *common.Event, params map[string]interface{})
 
 	// Name returns the name of the alert plugin.
 	Name() string
 }
 
-// New generates a alert plugin based on the passed in plugin and user defined configuration.
+// New generates a alert plugin based on the passed in plugin and user defined configuration, if the configuration defines a 'renotify_interval' or 'group_window' the plugin is wrapped in a Throttle.
 func New(alertPlugin string, config *common.Config, pluginConfig *common.PluginConfig) (Alert, error) {
+	var alert Alert
+	var err error
+
 	switch alertPlugin {
 	case NoopAlert:
-		return newNoop(config)
+		alert, err = newNoop(config)
+	case WebhookAlert:
+		alert, err = newWebhook(config, pluginConfig)
+	default:
+		return nil, errors.New("specified alert plugin does not exist")
 	}
-	return nil, errors.New("specified alert plugin does not exist")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return newThrottle(config, pluginConfig, alert)
 }
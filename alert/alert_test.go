@@ -4,6 +4,9 @@
 package alert
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -33,10 +36,223 @@ func TestNoop(t *testing.T) {
 		},
 	}
 
-	noop.Emit(event)
+	noop.Emit(event, nil)
 
 	name := noop.Name()
 	if name != "Noop" {
 		t.Fatal("Something is very very wrong.")
 	}
 }
+
+func TestWebhook(t *testing.T) {
+	config := &common.Config{Log: common.NewLogger(common.NoopLogger)}
+
+	webhook, err := New(WebhookAlert, config, &common.PluginConfig{Name: "Testing Webhook", Type: "webhook", Config: map[string]string{}})
+	if err == nil || webhook != nil {
+		t.Fatal("webhook plugin did not throw an error when configured without a url definition.")
+	}
+
+	webhook, err = New(WebhookAlert, config, &common.PluginConfig{Name: "Testing Webhook", Type: "webhook", Config: map[string]string{"url": "http://localhost", "template": "{{ .Broken"}})
+	if err == nil || webhook != nil {
+		t.Fatal("webhook plugin did not throw an error when configured with an invalid template.")
+	}
+
+	received := make(chan *http.Request, 10)
+	bodies := make(chan map[string]interface{}, 10)
+	failures := 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		received <- r
+		bodies <- body
+	}))
+	defer server.Close()
+
+	webhook, err = New(WebhookAlert, config, &common.PluginConfig{
+		Name: "Testing Webhook",
+		Type: "webhook",
+		Config: map[string]string{
+			"url":                  server.URL + "/alert",
+			"template":             `{"alert": "{{ .Name }}", "message": {{ json (index .Event.Data "message") }}, "severity": {{ json .Params.severity }}}`,
+			"timeout":              "1s",
+			"retries":              "2",
+			"retry_interval":       "10ms",
+			"header_Authorization": "Bearer testing",
+		},
+	})
+	if err != nil {
+		t.Fatalf("webhook plugin threw an error for no reason: %s", err.Error())
+	}
+
+	event := &common.Event{
+		Timestamp: time.Now(),
+		Data: map[string]interface{}{
+			"message": "woot",
+		},
+	}
+
+	webhook.Emit(event, map[string]interface{}{"severity": "critical"})
+
+	select {
+	case r := <-received:
+		if r.Method != "POST" || r.URL.Path != "/alert" || r.Header.Get("Authorization") != "Bearer testing" {
+			t.Fatal("webhook plugin did not send the request to the configured endpoint with the configured headers.")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook plugin never delivered the alert after retrying.")
+	}
+
+	body := <-bodies
+	if body["alert"] != "Testing Webhook" || body["message"] != "woot" || body["severity"] != "critical" {
+		t.Fatal("webhook plugin did not properly render the body template.")
+	}
+
+	name := webhook.Name()
+	if name != "Testing Webhook" {
+		t.Fatal("Something is wrong name wasn't handled properly.")
+	}
+}
+
+func TestWebhookQueue(t *testing.T) {
+	config := &common.Config{Log: common.NewLogger(common.NoopLogger)}
+
+	webhook, err := New(WebhookAlert, config, &common.PluginConfig{Name: "Testing Webhook", Type: "webhook", Config: map[string]string{"url": "http://localhost", "queue_size": "0"}})
+	if err == nil || webhook != nil {
+		t.Fatal("webhook plugin did not throw an error when configured with an invalid queue_size.")
+	}
+
+	received := make(chan struct{}, 10)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		<-release
+	}))
+	defer server.Close()
+
+	webhook, err = New(WebhookAlert, config, &common.PluginConfig{
+		Name: "Testing Webhook",
+		Type: "webhook",
+		Config: map[string]string{
+			"url":        server.URL,
+			"retries":    "0",
+			"queue_size": "1",
+			"workers":    "1",
+		},
+	})
+	if err != nil {
+		t.Fatalf("webhook plugin threw an error for no reason: %s", err.Error())
+	}
+
+	event := &common.Event{Timestamp: time.Now(), Data: map[string]interface{}{}}
+	webhook.Emit(event, nil)
+
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook plugin never delivered the alert.")
+	}
+
+	// The single worker is blocked on the endpoint, so only one more alert fits in the queue and the rest are dropped.
+	for i := 0; i < 5; i++ {
+		webhook.Emit(event, nil)
+	}
+	close(release)
+
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook plugin never delivered the queued alert.")
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if len(received) != 0 {
+		t.Fatal("webhook plugin delivered alerts emitted while the queue was full.")
+	}
+}
+
+type recorder struct {
+	events chan *common.Event
+	params chan map[string]interface{}
+}
+
+func (r *recorder) Emit(event *common.Event, params map[string]interface{}) {
+	r.events <- event
+	r.params <- params
+}
+
+func (r *recorder) Name() string {
+	return "Recorder"
+}
+
+func TestThrottle(t *testing.T) {
+	config := &common.Config{Log: common.NewLogger(common.NoopLogger)}
+	rec := &recorder{events: make(chan *common.Event, 10), params: make(chan map[string]interface{}, 10)}
+
+	throttle, err := newThrottle(config, &common.PluginConfig{Name: "Testing Throttle", Config: map[string]string{"renotify_interval": "woot"}}, rec)
+	if err == nil || throttle != nil {
+		t.Fatal("throttle did not throw an error when configured with an invalid renotify_interval.")
+	}
+
+	throttle, err = newThrottle(config, &common.PluginConfig{Name: "Testing Throttle", Config: map[string]string{"dedup_key": "host"}}, rec)
+	if err == nil || throttle != nil {
+		t.Fatal("throttle did not throw an error when configured with a dedup_key but no renotify_interval or group_window.")
+	}
+
+	throttle, err = newThrottle(config, &common.PluginConfig{Name: "Testing Throttle", Config: map[string]string{}}, rec)
+	if err != nil || throttle != rec {
+		t.Fatal("throttle wrapped an alert plugin that has no throttling configured.")
+	}
+
+	throttle, err = newThrottle(config, &common.PluginConfig{Name: "Testing Throttle", Config: map[string]string{"dedup_key": "host", "renotify_interval": "1h"}}, rec)
+	if err != nil {
+		t.Fatalf("throttle threw an error for no reason: %s", err.Error())
+	}
+
+	throttle.Emit(&common.Event{Data: map[string]interface{}{"host": "a"}}, nil)
+	throttle.Emit(&common.Event{Data: map[string]interface{}{"host": "a"}}, nil)
+	throttle.Emit(&common.Event{Data: map[string]interface{}{"host": "b"}}, nil)
+
+	if len(rec.events) != 2 {
+		t.Fatalf("throttle passed on %d alerts, expected one per deduplication key.", len(rec.events))
+	}
+	<-rec.events
+	<-rec.events
+	<-rec.params
+	<-rec.params
+
+	if throttle.Name() != "Recorder" {
+		t.Fatal("Something is wrong name wasn't handled properly.")
+	}
+
+	throttle, err = newThrottle(config, &common.PluginConfig{Name: "Testing Throttle", Config: map[string]string{"dedup_key": "host", "group_window": "100ms"}}, rec)
+	if err != nil {
+		t.Fatalf("throttle threw an error for no reason: %s", err.Error())
+	}
+
+	for i := 0; i < 3; i++ {
+		throttle.Emit(&common.Event{Data: map[string]interface{}{"host": "a"}}, map[string]interface{}{"severity": i})
+	}
+
+	select {
+	case event := <-rec.events:
+		if event.Data[ThrottleCountField] != 3 || event.Data["host"] != "a" {
+			t.Fatal("throttle did not group the alerts into a single notification with a count.")
+		}
+		if params := <-rec.params; params["severity"] != 0 {
+			t.Fatal("throttle did not pass the params of the first grouped alert through.")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("throttle never flushed the grouped alerts.")
+	}
+
+	if len(rec.events) != 0 {
+		t.Fatal("throttle sent more than one notification for a single group.")
+	}
+}
@@ -7,7 +7,10 @@ Package alert contains the interfaces, structs, and logic that form the basis of
 Protond currently implements the following alert plugins:
   - Noop
     - A no alert which just noops the event emission.
-  - Http
-    - An http alert that allows for emitting events to the specified endpoint for alerting.
+  - Webhook
+    - An http alert that allows for emitting events to the specified endpoint for alerting, using a templated json body with custom headers, a timeout and retries.
+      Alerts are delivered by a fixed pool of 'workers' from a queue holding up to 'queue_size' alerts, alerts emitted while the queue is full are dropped and logged.
+
+Any alert plugin can additionally be configured with the 'dedup_key', 'renotify_interval' and 'group_window' keys, in which case it is wrapped in a Throttle that deduplicates alerts by the listed event fields, suppresses re-notifications within the interval, and groups alerts arriving within the window into a single notification carrying an 'alert_count' field, a 'dedup_key' requires either a 'renotify_interval' or a 'group_window'.
 */
 package alert
@@ -14,7 +14,7 @@ type Noop struct {
 }
 
 // Emit will return an empty list of events.
-func (noop *Noop) Emit(event *common.Event) {
+func (noop *Noop) Emit(event *common.Event, params map[string]interface{}) {
 	return
 }
 
@@ -0,0 +1,181 @@
+// Copyright (c) 2017 Christian Saide <Supernomad>
+// Licensed under the MPL-2.0, for details see https://github.com/Supernomad/protond/blob/master/LICENSE
+
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Supernomad/protond/common"
+)
+
+const (
+	// ThrottleCountField is the event field that grouped alert notifications use to report how many alerts were folded into them.
+	ThrottleCountField = "alert_count"
+)
+
+type throttleState struct {
+	notified time.Time
+	pending  *common.Event
+	params   map[string]interface{}
+	count    int
+}
+
+// Throttle is a struct that wraps an arbitrary alert plugin, deduplicating, rate limiting and grouping the alerts passed to it.
+type Throttle struct {
+	config       *common.Config
+	pluginConfig *common.PluginConfig
+	alert        Alert
+
+	fields   []string
+	renotify time.Duration
+	window   time.Duration
+
+	mutex  sync.Mutex
+	states map[string]*throttleState
+	pruned time.Time
+}
+
+func (throttle *Throttle) key(event *common.Event) string {
+	values := make([]string, len(throttle.fields))
+	for i, field := range throttle.fields {
+		values[i] = fmt.Sprint(event.Data[field])
+	}
+	return strings.Join(values, "|")
+}
+
+func (throttle *Throttle) prune(now time.Time) {
+	interval := throttle.renotify
+	if interval < throttle.window {
+		interval = throttle.window
+	}
+
+	if now.Sub(throttle.pruned) < interval {
+		return
+	}
+
+	for key, state := range throttle.states {
+		if state.pending == nil && now.Sub(state.notified) >= throttle.renotify {
+			delete(throttle.states, key)
+		}
+	}
+	throttle.pruned = now
+}
+
+func (throttle *Throttle) flush(key string) {
+	throttle.mutex.Lock()
+	state := throttle.states[key]
+	event, params, count := state.pending, state.params, state.count
+
+	state.pending = nil
+	state.params = nil
+	state.count = 0
+	state.notified = time.Now()
+	throttle.mutex.Unlock()
+
+	data := make(map[string]interface{}, len(event.Data)+1)
+	for k, v := range event.Data {
+		data[k] = v
+	}
+	data[ThrottleCountField] = count
+
+	throttle.alert.Emit(&common.Event{
+		Timestamp: event.Timestamp,
+		Input:     event.Input,
+		Data:      data,
+	}, params)
+}
+
+// Emit passes the supplied event on to the wrapped alert plugin, unless an alert with the same deduplication key was already sent within the re-notify interval, or is currently being grouped with other alerts.
+func (throttle *Throttle) Emit(event *common.Event, params map[string]interface{}) {
+	now := time.Now()
+	key := throttle.key(event)
+
+	throttle.mutex.Lock()
+	throttle.prune(now)
+
+	state, ok := throttle.states[key]
+	if !ok {
+		state = &throttleState{}
+		throttle.states[key] = state
+	}
+
+	if state.pending != nil {
+		state.count++
+		throttle.mutex.Unlock()
+		return
+	}
+
+	if !state.notified.IsZero() && now.Sub(state.notified) < throttle.renotify {
+		throttle.mutex.Unlock()
+		throttle.config.Log.Debug.Printf("[ALERT] [THROTTLE] Alert, '%s', suppressed duplicate alert with key '%s'.", throttle.Name(), key)
+		return
+	}
+
+	if throttle.window > 0 {
+		state.pending = event
+		state.params = params
+		state.count = 1
+		throttle.mutex.Unlock()
+
+		time.AfterFunc(throttle.window, func() { throttle.flush(key) })
+		return
+	}
+
+	state.notified = now
+	throttle.mutex.Unlock()
+
+	throttle.alert.Emit(event, params)
+}
+
+// Name returns the name of the wrapped alert plugin.
+func (throttle *Throttle) Name() string {
+	return throttle.alert.Name()
+}
+
+func newThrottle(config *common.Config, pluginConfig *common.PluginConfig, alert Alert) (Alert, error) {
+	if pluginConfig == nil {
+		return alert, nil
+	}
+
+	throttle := &Throttle{
+		config:       config,
+		pluginConfig: pluginConfig,
+		alert:        alert,
+		states:       make(map[string]*throttleState),
+	}
+
+	var err error
+	if raw := pluginConfig.Config["renotify_interval"]; raw != "" {
+		throttle.renotify, err = time.ParseDuration(raw)
+		if err != nil {
+			return nil, errors.New("configuration for the alert plugin, '" + pluginConfig.Name + "', has an invalid renotify_interval, expected a 'duration' for example: '10m'")
+		}
+	}
+
+	if raw := pluginConfig.Config["group_window"]; raw != "" {
+		throttle.window, err = time.ParseDuration(raw)
+		if err != nil {
+			return nil, errors.New("configuration for the alert plugin, '" + pluginConfig.Name + "', has an invalid group_window, expected a 'duration' for example: '30s'")
+		}
+	}
+
+	if raw := pluginConfig.Config["dedup_key"]; raw != "" {
+		for _, field := range strings.Split(raw, ",") {
+			throttle.fields = append(throttle.fields, strings.TrimSpace(field))
+		}
+	}
+
+	if throttle.renotify == 0 && throttle.window == 0 {
+		if len(throttle.fields) > 0 {
+			return nil, errors.New("configuration for the alert plugin, '" + pluginConfig.Name + "', has a dedup_key without a renotify_interval or group_window, which is required to deduplicate alerts")
+		}
+		return alert, nil
+	}
+
+	return throttle, nil
+}
@@ -0,0 +1,218 @@
+// Copyright (c) 2017 Christian Saide <Supernomad>
+// Licensed under the MPL-2.0, for details see https://github.com/Supernomad/protond/blob/master/LICENSE
+
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/Supernomad/protond/common"
+)
+
+const (
+	webhookHeaderPrefix  = "header_"
+	webhookDefaultBody   = `{"event": {{ json .Event }}, "params": {{ json .Params }}}`
+	webhookDefaultMethod = "POST"
+	webhookDefaultQueue  = 100
+	webhookDefaultWorker = 4
+)
+
+var webhookFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		buf, err := json.Marshal(v)
+		return string(buf), err
+	},
+}
+
+// Webhook is a struct representing the webhook alert plugin.
+type Webhook struct {
+	config        *common.Config
+	pluginConfig  *common.PluginConfig
+	client        *http.Client
+	body          *template.Template
+	headers       map[string]string
+	method        string
+	url           string
+	retries       int
+	retryInterval time.Duration
+	queue         chan []byte
+	workers       int
+}
+
+type webhookContext struct {
+	Name   string
+	Event  *common.Event
+	Params map[string]interface{}
+}
+
+func (webhook *Webhook) render(event *common.Event, params map[string]interface{}) ([]byte, error) {
+	if params == nil {
+		params = make(map[string]interface{})
+	}
+
+	var buf bytes.Buffer
+	err := webhook.body.Execute(&buf, &webhookContext{
+		Name:   webhook.pluginConfig.Name,
+		Event:  event,
+		Params: params,
+	})
+	return buf.Bytes(), err
+}
+
+func (webhook *Webhook) send(body []byte) error {
+	req, err := http.NewRequest(webhook.method, webhook.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range webhook.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := webhook.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.New("webhook endpoint responded with status: " + resp.Status)
+	}
+	return nil
+}
+
+func (webhook *Webhook) deliver(body []byte) {
+	var err error
+	for attempt := 0; attempt <= webhook.retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(webhook.retryInterval)
+		}
+
+		if err = webhook.send(body); err == nil {
+			webhook.config.Log.Debug.Printf("[ALERT] [WEBHOOK] Alert, '%s', delivered to '%s'.", webhook.pluginConfig.Name, webhook.url)
+			return
+		}
+
+		webhook.config.Log.Warn.Printf("[ALERT] [WEBHOOK] Alert, '%s', failed delivery attempt %d to '%s': %s", webhook.pluginConfig.Name, attempt+1, webhook.url, err.Error())
+	}
+
+	webhook.config.Log.Error.Printf("[ALERT] [WEBHOOK] Alert, '%s', giving up on delivery to '%s' after %d attempts.", webhook.pluginConfig.Name, webhook.url, webhook.retries+1)
+}
+
+func (webhook *Webhook) worker() {
+	for body := range webhook.queue {
+		webhook.deliver(body)
+	}
+}
+
+// Emit renders the configured body template for the supplied event and params and queues it to be POSTed to the configured url, delivery happens in the background so that filters are never blocked on the remote endpoint, and alerts are dropped while the queue is full.
+func (webhook *Webhook) Emit(event *common.Event, params map[string]interface{}) {
+	body, err := webhook.render(event, params)
+	if err != nil {
+		webhook.config.Log.Error.Printf("[ALERT] [WEBHOOK] Alert, '%s', failed to render the body template: %s", webhook.pluginConfig.Name, err.Error())
+		return
+	}
+
+	select {
+	case webhook.queue <- body:
+	default:
+		webhook.config.Log.Error.Printf("[ALERT] [WEBHOOK] Alert, '%s', dropped an alert as the delivery queue to '%s' is full.", webhook.pluginConfig.Name, webhook.url)
+	}
+}
+
+// Name returns the name of the webhook alert plugin.
+func (webhook *Webhook) Name() string {
+	return webhook.pluginConfig.Name
+}
+
+func newWebhook(config *common.Config, pluginConfig *common.PluginConfig) (Alert, error) {
+	webhook := &Webhook{
+		config:        config,
+		pluginConfig:  pluginConfig,
+		headers:       make(map[string]string),
+		method:        webhookDefaultMethod,
+		retries:       3,
+		retryInterval: time.Second,
+		workers:       webhookDefaultWorker,
+	}
+
+	if pluginConfig.Config["url"] == "" {
+		return nil, errors.New("configuration for the webhook alert plugin, '" + pluginConfig.Name + "', is missing a url definition")
+	}
+	webhook.url = pluginConfig.Config["url"]
+
+	if method := pluginConfig.Config["method"]; method != "" {
+		webhook.method = strings.ToUpper(method)
+	}
+
+	body := pluginConfig.Config["template"]
+	if body == "" {
+		body = webhookDefaultBody
+	}
+
+	tmpl, err := template.New(pluginConfig.Name).Funcs(webhookFuncs).Parse(body)
+	if err != nil {
+		return nil, errors.New("configuration for the webhook alert plugin, '" + pluginConfig.Name + "', has an invalid template: " + err.Error())
+	}
+	webhook.body = tmpl
+
+	timeout := 10 * time.Second
+	if raw := pluginConfig.Config["timeout"]; raw != "" {
+		timeout, err = time.ParseDuration(raw)
+		if err != nil {
+			return nil, errors.New("configuration for the webhook alert plugin, '" + pluginConfig.Name + "', has an invalid timeout, expected a 'duration' for example: '10s'")
+		}
+	}
+	webhook.client = &http.Client{Timeout: timeout}
+
+	if raw := pluginConfig.Config["retries"]; raw != "" {
+		webhook.retries, err = strconv.Atoi(raw)
+		if err != nil || webhook.retries < 0 {
+			return nil, errors.New("configuration for the webhook alert plugin, '" + pluginConfig.Name + "', has an invalid retries definition, expected a positive 'int'")
+		}
+	}
+
+	if raw := pluginConfig.Config["retry_interval"]; raw != "" {
+		webhook.retryInterval, err = time.ParseDuration(raw)
+		if err != nil {
+			return nil, errors.New("configuration for the webhook alert plugin, '" + pluginConfig.Name + "', has an invalid retry_interval, expected a 'duration' for example: '1s'")
+		}
+	}
+
+	queueSize := webhookDefaultQueue
+	if raw := pluginConfig.Config["queue_size"]; raw != "" {
+		queueSize, err = strconv.Atoi(raw)
+		if err != nil || queueSize <= 0 {
+			return nil, errors.New("configuration for the webhook alert plugin, '" + pluginConfig.Name + "', has an invalid queue_size definition, expected a positive 'int'")
+		}
+	}
+
+	if raw := pluginConfig.Config["workers"]; raw != "" {
+		webhook.workers, err = strconv.Atoi(raw)
+		if err != nil || webhook.workers <= 0 {
+			return nil, errors.New("configuration for the webhook alert plugin, '" + pluginConfig.Name + "', has an invalid workers definition, expected a positive 'int'")
+		}
+	}
+
+	for key, value := range pluginConfig.Config {
+		if strings.HasPrefix(key, webhookHeaderPrefix) {
+			webhook.headers[strings.TrimPrefix(key, webhookHeaderPrefix)] = value
+		}
+	}
+
+	// Deliveries are made by a fixed number of workers, so a burst of alerts against a slow endpoint can't pile up goroutines and connections.
+	webhook.queue = make(chan []byte, queueSize)
+	for i := 0; i < webhook.workers; i++ {
+		go webhook.worker()
+	}
+
+	return webhook, nil
+}
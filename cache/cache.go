@@ -5,6 +5,7 @@ package cache
 
 import (
 	"errors"
+	"time"
 
 	"github.com/Supernomad/protond/common"
 )
@@ -15,6 +16,9 @@ const (
 
 	// MemoryCache defines an in memory cache.
 	MemoryCache = "memory"
+
+	// DiskCache defines an in memory cache that is persisted to disk.
+	DiskCache = "disk"
 )
 
 // Cache is the interface that plugins must adhere to for operation as a cache plugin.
@@ -25,8 +29,26 @@ type Cache interface {
 	// Store should add an event to an existing list of events or create a new one.
 	Store(key string, event *common.Event)
 
+	// GetSince should return the list of events associated with the given key whose timestamp is not before the supplied time.
+	GetSince(key string, since time.Time) []*common.Event
+
+	// Len should return the number of events associated with the given key.
+	Len(key string) int
+
+	// Keys should return the sorted list of keys beginning with the supplied prefix that have events or a counter associated with them.
+	Keys(prefix string) []string
+
+	// Delete should remove the events and the counter associated with the given key.
+	Delete(key string)
+
+	// Incr should atomically add n to the counter associated with the given key and return the new value, a counter that doesn't exist or has expired starts from zero and expires after the supplied ttl, a ttl of zero means the counter has no expiry of its own, although the cache plugin may still expire or evict it.
+	Incr(key string, n int64, ttl time.Duration) int64
+
 	// Name returns the name of the cache plugin.
 	Name() string
+
+	// Close should release any resources held by the cache plugin, such as background goroutines.
+	Close() error
 }
 
 // New generates a cache plugin based on the passed in plugin and user defined configuration.
@@ -36,6 +58,8 @@ func New(cachePlugin string, config *common.Config, pluginConfig *common.PluginC
 		return newNoop(config)
 	case MemoryCache:
 		return newMemory(config, pluginConfig)
+	case DiskCache:
+		return newDisk(config, pluginConfig)
 	}
 	return nil, errors.New("specified cache plugin does not exist")
 }
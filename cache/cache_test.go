@@ -4,6 +4,12 @@
 package cache
 
 import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -32,6 +38,11 @@ func TestNoop(t *testing.T) {
 	}
 
 	noop.Store("test", nil)
+	noop.Delete("test")
+
+	if len(noop.GetSince("test", time.Now())) != 0 || noop.Len("test") != 0 || len(noop.Keys("")) != 0 || noop.Incr("test", 2, 0) != 2 || noop.Close() != nil {
+		t.Fatal("Something is very very wrong.")
+	}
 
 	name := noop.Name()
 	if name != "Noop" {
@@ -71,3 +82,303 @@ func TestMemory(t *testing.T) {
 		t.Fatal("Something is very very wrong.")
 	}
 }
+
+func TestMemoryGetReturnsCopy(t *testing.T) {
+	memory, _ := New(MemoryCache, nil, &common.PluginConfig{Name: "Memory Test"})
+	defer memory.Close()
+
+	event := &common.Event{Timestamp: time.Now(), Data: map[string]interface{}{"message": "woot"}}
+	memory.Store("test", event)
+
+	test := memory.Get("test")
+	test[0] = nil
+
+	if test = memory.Get("test"); len(test) != 1 || test[0] != event {
+		t.Fatal("memory cache Get returned the internal list of events instead of a copy.")
+	}
+
+	if memory.Get("missing") != nil {
+		t.Fatal("memory cache Get returned events for a key that doesn't exist.")
+	}
+}
+
+func TestMemoryConcurrency(t *testing.T) {
+	memory, _ := New(MemoryCache, nil, &common.PluginConfig{Name: "Memory Test"})
+	defer memory.Close()
+
+	workers := 16
+	iterations := 500
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+
+			own := "worker-" + strconv.Itoa(worker)
+			for j := 0; j < iterations; j++ {
+				event := &common.Event{Timestamp: time.Now(), Data: map[string]interface{}{"worker": worker, "iteration": j}}
+
+				memory.Store("shared", event)
+				memory.Store(own, event)
+
+				events := memory.Get("shared")
+				events = append(events, event)
+				for k := range events {
+					_ = events[k].Data["worker"]
+				}
+
+				if len(memory.Get(own)) != j+1 {
+					t.Errorf("memory cache lost events stored by worker %d.", worker)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if len(memory.Get("shared")) != workers*iterations {
+		t.Fatal("memory cache lost events stored concurrently under the same key.")
+	}
+}
+
+func TestMemoryMaxLength(t *testing.T) {
+	memory, err := New(MemoryCache, nil, &common.PluginConfig{Name: "Memory Test", Config: map[string]string{"max_length": "3"}})
+	if err != nil {
+		t.Fatalf("Something is very very wrong. %s", err.Error())
+	}
+	defer memory.Close()
+
+	events := make([]*common.Event, 10)
+	for i := range events {
+		events[i] = &common.Event{Timestamp: time.Now(), Data: map[string]interface{}{"i": i}}
+		memory.Store("test", events[i])
+	}
+
+	test := memory.Get("test")
+	if len(test) != 3 || test[0] != events[7] || test[1] != events[8] || test[2] != events[9] {
+		t.Fatal("memory cache did not keep only the newest events of the key.")
+	}
+}
+
+func TestMemoryMaxKeys(t *testing.T) {
+	memory, err := New(MemoryCache, nil, &common.PluginConfig{Name: "Memory Test", Config: map[string]string{"max_keys": "2"}})
+	if err != nil {
+		t.Fatalf("Something is very very wrong. %s", err.Error())
+	}
+	defer memory.Close()
+
+	event := &common.Event{Timestamp: time.Now(), Data: map[string]interface{}{}}
+	memory.Store("a", event)
+	memory.Store("b", event)
+	memory.Get("a")
+	memory.Store("c", event)
+
+	if memory.Get("b") != nil || memory.Get("a") == nil || memory.Get("c") == nil {
+		t.Fatal("memory cache did not evict the least recently used key.")
+	}
+}
+
+func TestMemoryTTL(t *testing.T) {
+	memory, err := New(MemoryCache, nil, &common.PluginConfig{Name: "Memory Test", Config: map[string]string{"ttl": "50ms", "sweep_interval": "10ms"}})
+	if err != nil {
+		t.Fatalf("Something is very very wrong. %s", err.Error())
+	}
+	defer memory.Close()
+
+	old := &common.Event{Timestamp: time.Now(), Data: map[string]interface{}{}}
+	memory.Store("test", old)
+	memory.Store("swept", old)
+	time.Sleep(60 * time.Millisecond)
+
+	fresh := &common.Event{Timestamp: time.Now(), Data: map[string]interface{}{}}
+	memory.Store("test", fresh)
+
+	if test := memory.Get("test"); len(test) != 1 || test[0] != fresh {
+		t.Fatal("memory cache returned an expired event.")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	m := memory.(*Memory)
+	m.lock.Lock()
+	_, ok := m.entries["swept"]
+	m.lock.Unlock()
+	if ok {
+		t.Fatal("memory cache sweeper did not remove the key left without any events.")
+	}
+}
+
+func TestMemoryInvalidConfig(t *testing.T) {
+	bad := []map[string]string{
+		{"max_length": "woot"},
+		{"max_keys": "-1"},
+		{"ttl": "woot"},
+		{"ttl": "1m", "sweep_interval": "0s"},
+	}
+	for _, cfg := range bad {
+		if _, err := New(MemoryCache, nil, &common.PluginConfig{Name: "Memory Test", Config: cfg}); err == nil {
+			t.Fatalf("memory cache accepted the invalid configuration: %v", cfg)
+		}
+	}
+}
+
+func TestMemoryQueries(t *testing.T) {
+	memory, _ := New(MemoryCache, nil, &common.PluginConfig{Name: "Memory Test"})
+	defer memory.Close()
+
+	now := time.Now()
+	old := &common.Event{Timestamp: now.Add(-time.Hour), Data: map[string]interface{}{}}
+	recent := &common.Event{Timestamp: now, Data: map[string]interface{}{}}
+
+	memory.Store("login:bob", old)
+	memory.Store("login:bob", recent)
+	memory.Store("login:alice", recent)
+	memory.Store("other", recent)
+	memory.Incr("login:counter", 1, 0)
+
+	if memory.Len("login:bob") != 2 || memory.Len("missing") != 0 {
+		t.Fatal("memory cache Len did not return the number of stored events.")
+	}
+
+	if test := memory.GetSince("login:bob", now.Add(-10*time.Minute)); len(test) != 1 || test[0] != recent {
+		t.Fatal("memory cache GetSince did not return only the events inside the window.")
+	}
+
+	keys := memory.Keys("login:")
+	if len(keys) != 3 || keys[0] != "login:alice" || keys[1] != "login:bob" || keys[2] != "login:counter" {
+		t.Fatalf("memory cache Keys returned the wrong keys: %v", keys)
+	}
+
+	memory.Delete("login:bob")
+	memory.Delete("login:counter")
+	if memory.Get("login:bob") != nil || len(memory.Keys("login:")) != 1 {
+		t.Fatal("memory cache Delete did not remove the key.")
+	}
+}
+
+func TestMemoryIncr(t *testing.T) {
+	memory, _ := New(MemoryCache, nil, &common.PluginConfig{Name: "Memory Test"})
+	defer memory.Close()
+
+	if memory.Incr("count", 1, 0) != 1 || memory.Incr("count", 5, 0) != 6 || memory.Incr("count", -2, 0) != 4 {
+		t.Fatal("memory cache Incr did not add to the counter.")
+	}
+
+	if memory.Incr("window", 1, 20*time.Millisecond) != 1 || memory.Incr("window", 1, 20*time.Millisecond) != 2 {
+		t.Fatal("memory cache Incr did not add to the counter with a ttl.")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if memory.Incr("window", 1, 20*time.Millisecond) != 1 || len(memory.Keys("count")) != 1 {
+		t.Fatal("memory cache Incr did not restart the expired counter.")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				memory.Incr("concurrent", 1, 0)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if memory.Incr("concurrent", 0, 0) != 1000 {
+		t.Fatal("memory cache Incr lost concurrent increments.")
+	}
+}
+
+func TestMemoryIncrBounds(t *testing.T) {
+	memory, err := New(MemoryCache, nil, &common.PluginConfig{Name: "Memory Test", Config: map[string]string{"max_keys": "2", "ttl": "50ms", "sweep_interval": "10ms"}})
+	if err != nil {
+		t.Fatalf("Something is very very wrong. %s", err.Error())
+	}
+	defer memory.Close()
+
+	memory.Store("events", &common.Event{Timestamp: time.Now(), Data: map[string]interface{}{}})
+	memory.Incr("a", 1, 0)
+	memory.Incr("b", 1, 0)
+
+	if keys := memory.Keys(""); len(keys) != 2 || keys[0] != "a" || keys[1] != "b" {
+		t.Fatalf("memory cache did not evict the least recently used key for a new counter: %v", keys)
+	}
+
+	memory.Incr("a", 1, 0)
+	memory.Incr("c", 1, 0)
+	if memory.Incr("a", 0, 0) != 2 || memory.Incr("b", 0, 0) != 0 {
+		t.Fatal("memory cache did not evict the least recently used counter.")
+	}
+
+	time.Sleep(80 * time.Millisecond)
+	m := memory.(*Memory)
+	m.lock.Lock()
+	counters, size := len(m.counters), m.recent.Len()
+	m.lock.Unlock()
+	if counters != 0 || size != 0 {
+		t.Fatal("memory cache sweeper did not remove the counters that went the ttl without being incremented.")
+	}
+}
+
+func TestDisk(t *testing.T) {
+	dir, _ := ioutil.TempDir(os.TempDir(), "disk")
+	defer os.RemoveAll(dir)
+
+	config := &common.Config{DataDir: dir, Log: common.NewLogger(common.NoopLogger)}
+	pluginConfig := &common.PluginConfig{Name: "disk", Config: map[string]string{"compact_threshold": "5"}}
+
+	disk, err := New(DiskCache, config, pluginConfig)
+	if err != nil {
+		t.Fatalf("Something is very very wrong. %s", err.Error())
+	}
+
+	timestamp := time.Now().Add(-time.Minute).Round(time.Millisecond)
+	for i := 0; i < 10; i++ {
+		disk.Store("events", &common.Event{Timestamp: timestamp, Input: "syslog", Tags: []string{"audit"}, Data: map[string]interface{}{"i": i}})
+	}
+	disk.Store("deleted", &common.Event{Timestamp: timestamp, Data: map[string]interface{}{}})
+	disk.Delete("deleted")
+	disk.Incr("counter", 3, 0)
+	disk.Incr("counter", 4, 0)
+	disk.Incr("expired", 1, time.Millisecond)
+
+	if err := disk.Close(); err != nil {
+		t.Fatalf("disk cache failed to close: %s", err.Error())
+	}
+
+	// Simulate a crash part way through writing a record.
+	file, _ := os.OpenFile(path.Join(dir, "cache", "disk.log"), os.O_APPEND|os.O_WRONLY, 0644)
+	file.WriteString(`{"op":"store","key":"events","event":{"data":`)
+	file.Close()
+
+	time.Sleep(5 * time.Millisecond)
+	disk, err = New(DiskCache, config, pluginConfig)
+	if err != nil {
+		t.Fatalf("disk cache failed to reload: %s", err.Error())
+	}
+	defer disk.Close()
+
+	events := disk.Get("events")
+	if len(events) != 10 || events[9].Data["i"].(float64) != 9 || !events[0].Timestamp.Equal(timestamp) || events[0].Input != "syslog" || !events[0].HasTag("audit") {
+		t.Fatal("disk cache did not restore the stored events.")
+	}
+	if disk.Len("deleted") != 0 || disk.Incr("counter", 0, 0) != 7 || disk.Incr("expired", 0, 0) != 0 {
+		t.Fatal("disk cache did not restore the deletes and counters.")
+	}
+
+	buf, _ := ioutil.ReadFile(path.Join(dir, "cache", "disk.log"))
+	if lines := strings.Count(string(buf), "\n"); lines != 13 {
+		t.Fatalf("disk cache did not compact the log on start up, found %d records.", lines)
+	}
+}
+
+func TestDiskInvalidConfig(t *testing.T) {
+	if _, err := New(DiskCache, nil, &common.PluginConfig{Name: "disk"}); err == nil {
+		t.Fatal("disk cache accepted a configuration without a path or data directory.")
+	}
+
+	if _, err := New(DiskCache, nil, &common.PluginConfig{Name: "disk", Config: map[string]string{"path": path.Join(os.TempDir(), "disk.log"), "compact_threshold": "woot"}}); err == nil {
+		t.Fatal("disk cache accepted an invalid compact_threshold.")
+	}
+}
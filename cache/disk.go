@@ -0,0 +1,331 @@
+// Copyright (c) 2017 Christian Saide <Supernomad>
+// Licensed under the MPL-2.0, for details see https://github.com/Supernomad/protond/blob/master/LICENSE
+
+package cache
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Supernomad/protond/common"
+)
+
+const (
+	diskStore   = "store"
+	diskDelete  = "delete"
+	diskCounter = "counter"
+
+	defaultCompactThreshold = 10000
+)
+
+// diskRecord is a single entry in the append only log of the disk cache.
+type diskRecord struct {
+	Op      string        `json:"op"`
+	Key     string        `json:"key"`
+	Event   *common.Event `json:"event,omitempty"`
+	Stored  time.Time     `json:"stored"`
+	Value   int64         `json:"value,omitempty"`
+	Expires time.Time     `json:"expires"`
+}
+
+// Disk is a struct representing the disk backed cache plugin, it keeps the same state as the memory cache and persists every change to an append only log, which is replayed on start up and periodically compacted.
+type Disk struct {
+	*Memory
+
+	path     string
+	file     *os.File
+	writer   *bufio.Writer
+	lockFile *os.File
+	lock     sync.Mutex
+
+	records   int
+	threshold int
+	compactAt int
+}
+
+func (disk *Disk) append(record *diskRecord) {
+	buf, err := json.Marshal(record)
+	if err == nil {
+		buf = append(buf, '\n')
+		_, err = disk.writer.Write(buf)
+	}
+	if err == nil {
+		err = disk.writer.Flush()
+	}
+	if err != nil {
+		disk.log().Error.Printf("[CACHE] [DISK] Cache, '%s', failed to write to '%s': %s", disk.pluginConfig.Name, disk.path, err.Error())
+		return
+	}
+
+	disk.records++
+	if disk.records >= disk.compactAt {
+		if err := disk.compact(); err != nil {
+			disk.log().Error.Printf("[CACHE] [DISK] Cache, '%s', failed to compact '%s': %s", disk.pluginConfig.Name, disk.path, err.Error())
+		}
+	}
+}
+
+func (disk *Disk) log() *common.Logger {
+	if disk.config == nil || disk.config.Log == nil {
+		return common.NewLogger(common.NoopLogger)
+	}
+	return disk.config.Log
+}
+
+// Store will append the supplied event to the list of events stored under the supplied key and persist it.
+func (disk *Disk) Store(key string, event *common.Event) {
+	disk.lock.Lock()
+	defer disk.lock.Unlock()
+
+	stored := time.Now()
+	disk.storeAt(key, event, stored)
+	disk.append(&diskRecord{Op: diskStore, Key: key, Event: event, Stored: stored})
+}
+
+// Delete will remove the events and the counter stored under the supplied key and persist the removal.
+func (disk *Disk) Delete(key string) {
+	disk.lock.Lock()
+	defer disk.lock.Unlock()
+
+	disk.Memory.Delete(key)
+	disk.append(&diskRecord{Op: diskDelete, Key: key})
+}
+
+// Incr will add n to the counter stored under the supplied key, persist the new value, and return it.
+func (disk *Disk) Incr(key string, n int64, ttl time.Duration) int64 {
+	disk.lock.Lock()
+	defer disk.lock.Unlock()
+
+	counter := disk.incr(key, n, ttl)
+	disk.append(&diskRecord{Op: diskCounter, Key: key, Value: counter.value, Expires: counter.expires})
+	return counter.value
+}
+
+// replay applies every record in the log to the in memory state, a truncated final record left by a crash is skipped.
+func (disk *Disk) replay() error {
+	file, err := os.Open(disk.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for line := 1; ; line++ {
+		buf, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(buf) > 0 {
+				disk.log().Warn.Printf("[CACHE] [DISK] Cache, '%s', skipping the truncated record at line %d of '%s'.", disk.pluginConfig.Name, line, disk.path)
+			}
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		var record diskRecord
+		if err := json.Unmarshal(buf, &record); err != nil {
+			return errors.New("the cache log '" + disk.path + "' is corrupt at line " + strconv.Itoa(line) + ": " + err.Error())
+		}
+
+		switch record.Op {
+		case diskStore:
+			if record.Event != nil {
+				disk.storeAt(record.Key, record.Event, record.Stored)
+			}
+		case diskDelete:
+			disk.Memory.Delete(record.Key)
+		case diskCounter:
+			counter := &memoryCounter{value: record.Value, expires: record.Expires}
+			if !counter.expired(time.Now()) {
+				disk.setCounter(record.Key, counter)
+			}
+		}
+	}
+}
+
+// compact rewrites the log so that it only contains the live state of the cache, the least recently used keys are written first so that replaying the log restores the same eviction order.
+func (disk *Disk) compact() error {
+	tmp := disk.path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	records := 0
+
+	disk.Memory.lock.Lock()
+	now := time.Now()
+	cutoff := disk.cutoff()
+	for element := disk.recent.Back(); element != nil && err == nil; element = element.Prev() {
+		switch value := element.Value.(type) {
+		case *memoryEntry:
+			value.expire(cutoff)
+			for _, item := range value.items[value.head:] {
+				if err = encoder.Encode(&diskRecord{Op: diskStore, Key: value.key, Event: item.event, Stored: item.stored}); err != nil {
+					break
+				}
+				records++
+			}
+		case *memoryCounter:
+			if value.expired(now) {
+				continue
+			}
+			err = encoder.Encode(&diskRecord{Op: diskCounter, Key: value.key, Value: value.value, Expires: value.expires})
+			records++
+		}
+	}
+	disk.Memory.lock.Unlock()
+
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := os.Rename(tmp, disk.path); err != nil {
+		return err
+	}
+
+	if disk.file != nil {
+		disk.file.Close()
+	}
+	if err := disk.open(); err != nil {
+		return err
+	}
+
+	disk.records = records
+	disk.compactAt = 2 * records
+	if disk.compactAt < disk.threshold {
+		disk.compactAt = disk.threshold
+	}
+	return nil
+}
+
+func (disk *Disk) open() error {
+	file, err := os.OpenFile(disk.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	disk.file = file
+	disk.writer = bufio.NewWriter(file)
+	return nil
+}
+
+// acquire takes an exclusive lock on the cache log, so that a reloaded protond process waits for the previous process to close the cache before replaying it.
+func (disk *Disk) acquire() error {
+	lockFile, err := os.OpenFile(disk.path+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+
+	if err := syscall.Flock(int(lockFile.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		disk.log().Info.Printf("[CACHE] [DISK] Cache, '%s', waiting for another process to release '%s'.", disk.pluginConfig.Name, disk.path)
+		if err := syscall.Flock(int(lockFile.Fd()), syscall.LOCK_EX); err != nil {
+			lockFile.Close()
+			return err
+		}
+	}
+
+	disk.lockFile = lockFile
+	return nil
+}
+
+func (disk *Disk) release() {
+	syscall.Flock(int(disk.lockFile.Fd()), syscall.LOCK_UN)
+	disk.lockFile.Close()
+}
+
+// Close will stop the background sweeper, and flush and release the cache log.
+func (disk *Disk) Close() error {
+	disk.lock.Lock()
+	defer disk.lock.Unlock()
+
+	if disk.file == nil {
+		return nil
+	}
+
+	disk.Memory.Close()
+
+	err := disk.writer.Flush()
+	if err == nil {
+		err = disk.file.Sync()
+	}
+	if closeErr := disk.file.Close(); err == nil {
+		err = closeErr
+	}
+	disk.file = nil
+
+	disk.release()
+	return err
+}
+
+func newDisk(config *common.Config, pluginConfig *common.PluginConfig) (Cache, error) {
+	memory, err := newMemory(config, pluginConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	disk := &Disk{
+		Memory:    memory.(*Memory),
+		path:      pluginConfig.Config["path"],
+		threshold: defaultCompactThreshold,
+	}
+
+	if disk.path == "" {
+		if config == nil || config.DataDir == "" {
+			disk.Memory.Close()
+			return nil, errors.New("configuration for the disk cache plugin, '" + pluginConfig.Name + "', is missing a path definition and no data directory is configured")
+		}
+		disk.path = path.Join(config.DataDir, "cache", pluginConfig.Name+".log")
+	}
+
+	if raw := pluginConfig.Config["compact_threshold"]; raw != "" {
+		disk.threshold, err = strconv.Atoi(raw)
+		if err != nil || disk.threshold <= 0 {
+			disk.Memory.Close()
+			return nil, errors.New("configuration for the disk cache plugin, '" + pluginConfig.Name + "', has an invalid compact_threshold definition, expected a positive 'int'")
+		}
+	}
+
+	if err := os.MkdirAll(path.Dir(disk.path), 0755); err != nil {
+		disk.Memory.Close()
+		return nil, err
+	}
+
+	if err := disk.acquire(); err != nil {
+		disk.Memory.Close()
+		return nil, err
+	}
+
+	// Replaying and then compacting the log drops every record that is no longer live, before any new records are appended.
+	if err := disk.replay(); err == nil {
+		err = disk.compact()
+	}
+	if err != nil {
+		disk.Memory.Close()
+		disk.release()
+		return nil, errors.New("disk cache plugin, '" + pluginConfig.Name + "', failed to load '" + disk.path + "': " + err.Error())
+	}
+
+	return disk, nil
+}
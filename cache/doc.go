@@ -8,6 +8,12 @@ Protond currently implements the following cache plugins:
   - Noop
     - A no operation cache which just returns a hard coded event and discards any new event added to it, this is used for testing protond.
   - Memory
-    - An in memory cache that allows for look backs of arbitrary size, only limited by memory available to the protond application.
+    - An in memory cache that allows for look backs of arbitrary size, only limited by memory available to the protond application, it is safe for concurrent use by every worker.
+      The 'max_length' parameter bounds the number of events kept per key discarding the oldest first, the 'max_keys' parameter bounds the number of keys holding events or a counter evicting the least recently used first, and the 'ttl' parameter expires events after the given duration along with counters incremented without a ttl of their own that go that long without being incremented.
+      Expired events and counters are removed by a background sweeper every 'ttl' or every minute, whichever is shorter, unless overridden by the 'sweep_interval' parameter, the internal cache is configured with the '--cache-ttl', '--cache-max-length', and '--cache-max-keys' options.
+  - Disk
+    - An in memory cache with the same parameters as the Memory cache, that persists every change to an append only log under the data directory, or at the 'path' parameter, so that cached events and counters survive restarts and reloads.
+      The log is replayed and compacted on start up, and compacted again whenever it grows past twice its compacted size or the 'compact_threshold' parameter, whichever is larger.
+      The internal cache uses the Disk cache when started with '--cache-type disk'.
 */
 package cache
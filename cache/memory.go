@@ -4,41 +4,403 @@
 package cache
 
 import (
+	"container/list"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/Supernomad/protond/common"
 )
 
-// Memory is a struct representing the standard input plugin.
+const maxSweepInterval = time.Minute
+
+type memoryItem struct {
+	event  *common.Event
+	stored time.Time
+}
+
+// memoryEntry is the list of events stored under a single key, the live events are items[head:] in the order they were stored.
+type memoryEntry struct {
+	key     string
+	items   []*memoryItem
+	head    int
+	element *list.Element
+}
+
+func (entry *memoryEntry) len() int {
+	return len(entry.items) - entry.head
+}
+
+// push appends the supplied item, discarding the oldest item once the entry holds maxLength items.
+func (entry *memoryEntry) push(item *memoryItem, maxLength int) {
+	if maxLength > 0 && entry.len() >= maxLength {
+		entry.pop()
+	}
+	entry.items = append(entry.items, item)
+}
+
+func (entry *memoryEntry) pop() {
+	entry.items[entry.head] = nil
+	entry.head++
+
+	// Reclaim the discarded prefix once it makes up half of the backing array, so that the array doesn't grow forever.
+	if entry.head >= len(entry.items)/2 {
+		entry.items = append(entry.items[:0], entry.items[entry.head:]...)
+		entry.head = 0
+	}
+}
+
+// expire discards the items stored before the supplied cutoff, as items are stored in order only the oldest items need to be checked.
+func (entry *memoryEntry) expire(cutoff time.Time) {
+	for entry.len() > 0 && entry.items[entry.head].stored.Before(cutoff) {
+		entry.pop()
+	}
+}
+
+type memoryCounter struct {
+	key     string
+	value   int64
+	expires time.Time
+	element *list.Element
+}
+
+func (counter *memoryCounter) expired(now time.Time) bool {
+	return !counter.expires.IsZero() && !now.Before(counter.expires)
+}
+
+// Memory is a struct representing the in memory cache plugin, it is safe for concurrent use by multiple workers.
+// The entries and counters share a single list ordered from the most to the least recently used, so that both count towards, and are evicted by, the maximum number of keys.
 type Memory struct {
 	config       *common.Config
 	pluginConfig *common.PluginConfig
-	events       map[string][]*common.Event
+	entries      map[string]*memoryEntry
+	recent       *list.List
+	counters     map[string]*memoryCounter
+	lock         sync.Mutex
+
+	maxLength int
+	maxKeys   int
+	ttl       time.Duration
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// cutoff returns the time before which stored events are expired, the zero time is returned when events never expire.
+func (memory *Memory) cutoff() time.Time {
+	if memory.ttl <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(-memory.ttl)
+}
+
+func (memory *Memory) remove(entry *memoryEntry) {
+	memory.recent.Remove(entry.element)
+	delete(memory.entries, entry.key)
 }
 
-// Get will return an empty list of events.
+func (memory *Memory) removeCounter(counter *memoryCounter) {
+	memory.recent.Remove(counter.element)
+	delete(memory.counters, counter.key)
+}
+
+// reserve evicts the least recently used entries and counters until there is room for one more, when the cache is bounded by a maximum number of keys.
+func (memory *Memory) reserve() {
+	if memory.maxKeys <= 0 {
+		return
+	}
+
+	for len(memory.entries)+len(memory.counters) >= memory.maxKeys {
+		switch oldest := memory.recent.Back().Value.(type) {
+		case *memoryEntry:
+			memory.remove(oldest)
+		case *memoryCounter:
+			memory.removeCounter(oldest)
+		}
+	}
+}
+
+// setCounter replaces the counter stored under the supplied key, marking it as the most recently used.
+func (memory *Memory) setCounter(key string, counter *memoryCounter) {
+	if old, ok := memory.counters[key]; ok {
+		memory.removeCounter(old)
+	}
+	memory.reserve()
+
+	counter.key = key
+	counter.element = memory.recent.PushFront(counter)
+	memory.counters[key] = counter
+}
+
+// lookup returns the live entry stored under the supplied key, expiring its old events and marking it as the most recently used.
+func (memory *Memory) lookup(key string) (*memoryEntry, bool) {
+	entry, ok := memory.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry.expire(memory.cutoff())
+	if entry.len() == 0 {
+		memory.remove(entry)
+		return nil, false
+	}
+
+	memory.recent.MoveToFront(entry.element)
+	return entry, true
+}
+
+// Get will return a copy of the list of events stored under the supplied key, so that callers never share the backing array with concurrent writers.
 func (memory *Memory) Get(key string) []*common.Event {
-	return memory.events[key]
+	memory.lock.Lock()
+	defer memory.lock.Unlock()
+
+	entry, ok := memory.lookup(key)
+	if !ok {
+		return nil
+	}
+
+	ret := make([]*common.Event, 0, entry.len())
+	for _, item := range entry.items[entry.head:] {
+		ret = append(ret, item.event)
+	}
+	return ret
 }
 
-// Store will memory the store process of a cache plugin.
+// Store will append the supplied event to the list of events stored under the supplied key, discarding the oldest event of the key once it holds the maximum number of events, and the least recently used key once the cache holds the maximum number of keys.
 func (memory *Memory) Store(key string, event *common.Event) {
-	if _, ok := memory.events[key]; ok {
-		memory.events[key] = append(memory.events[key], event)
+	memory.storeAt(key, event, time.Now())
+}
+
+func (memory *Memory) storeAt(key string, event *common.Event, stored time.Time) {
+	memory.lock.Lock()
+	defer memory.lock.Unlock()
+
+	entry, ok := memory.lookup(key)
+	if !ok {
+		memory.reserve()
+
+		entry = &memoryEntry{key: key, items: make([]*memoryItem, 0, 1)}
+		entry.element = memory.recent.PushFront(entry)
+		memory.entries[key] = entry
+	}
+
+	entry.push(&memoryItem{event: event, stored: stored}, memory.maxLength)
+}
+
+// GetSince will return a copy of the list of events stored under the supplied key whose timestamp is not before the supplied time.
+func (memory *Memory) GetSince(key string, since time.Time) []*common.Event {
+	memory.lock.Lock()
+	defer memory.lock.Unlock()
+
+	entry, ok := memory.lookup(key)
+	if !ok {
+		return nil
+	}
+
+	ret := make([]*common.Event, 0)
+	for _, item := range entry.items[entry.head:] {
+		if !item.event.Timestamp.Before(since) {
+			ret = append(ret, item.event)
+		}
+	}
+	return ret
+}
+
+// Len will return the number of events stored under the supplied key.
+func (memory *Memory) Len(key string) int {
+	memory.lock.Lock()
+	defer memory.lock.Unlock()
+
+	entry, ok := memory.lookup(key)
+	if !ok {
+		return 0
+	}
+	return entry.len()
+}
+
+// Keys will return the sorted list of keys beginning with the supplied prefix that have events or an unexpired counter stored under them.
+func (memory *Memory) Keys(prefix string) []string {
+	memory.lock.Lock()
+	defer memory.lock.Unlock()
+
+	cutoff := memory.cutoff()
+	keys := make([]string, 0)
+	for key, entry := range memory.entries {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+
+		entry.expire(cutoff)
+		if entry.len() > 0 {
+			keys = append(keys, key)
+		}
+	}
+
+	now := time.Now()
+	for key, counter := range memory.counters {
+		if _, ok := memory.entries[key]; ok || !strings.HasPrefix(key, prefix) || counter.expired(now) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// Delete will remove the events and the counter stored under the supplied key.
+func (memory *Memory) Delete(key string) {
+	memory.lock.Lock()
+	defer memory.lock.Unlock()
+
+	if entry, ok := memory.entries[key]; ok {
+		memory.remove(entry)
+	}
+	if counter, ok := memory.counters[key]; ok {
+		memory.removeCounter(counter)
+	}
+}
+
+// Incr will add n to the counter stored under the supplied key and return the new value, counters are kept separately from the events stored under the same key.
+// A counter incremented without a ttl of its own expires once it goes the ttl of the cache, if there is one, without being incremented.
+func (memory *Memory) Incr(key string, n int64, ttl time.Duration) int64 {
+	return memory.incr(key, n, ttl).value
+}
+
+// incr adds n to the counter stored under the supplied key, and returns a copy of the resulting counter.
+func (memory *Memory) incr(key string, n int64, ttl time.Duration) memoryCounter {
+	memory.lock.Lock()
+	defer memory.lock.Unlock()
+
+	now := time.Now()
+	counter, ok := memory.counters[key]
+	if !ok || counter.expired(now) {
+		counter = &memoryCounter{}
+		if ttl > 0 {
+			counter.expires = now.Add(ttl)
+		}
+		memory.setCounter(key, counter)
 	} else {
-		memory.events[key] = []*common.Event{event}
+		memory.recent.MoveToFront(counter.element)
+	}
+
+	if ttl <= 0 && memory.ttl > 0 {
+		counter.expires = now.Add(memory.ttl)
+	}
+	counter.value += n
+	return *counter
+}
+
+// Sweep will discard every expired event and counter, and every key left without any events.
+func (memory *Memory) Sweep() {
+	memory.lock.Lock()
+	defer memory.lock.Unlock()
+
+	cutoff := memory.cutoff()
+	for _, entry := range memory.entries {
+		entry.expire(cutoff)
+		if entry.len() == 0 {
+			memory.remove(entry)
+		}
+	}
+
+	now := time.Now()
+	for _, counter := range memory.counters {
+		if counter.expired(now) {
+			memory.removeCounter(counter)
+		}
 	}
 }
 
+func (memory *Memory) sweeper(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	defer close(memory.done)
+
+	for {
+		select {
+		case <-ticker.C:
+			memory.Sweep()
+		case <-memory.stop:
+			return
+		}
+	}
+}
+
+// Close will stop the background sweeper of the memory cache.
+func (memory *Memory) Close() error {
+	if memory.stop == nil {
+		return nil
+	}
+
+	close(memory.stop)
+	<-memory.done
+	memory.stop = nil
+	return nil
+}
+
 // Name returns the name of the memory cache.
 func (memory *Memory) Name() string {
 	return memory.pluginConfig.Name
 }
 
+func parseBound(pluginConfig *common.PluginConfig, key string) (int, error) {
+	raw := pluginConfig.Config[key]
+	if raw == "" {
+		return 0, nil
+	}
+
+	bound, err := strconv.Atoi(raw)
+	if err != nil || bound < 0 {
+		return 0, errors.New("configuration for the memory cache plugin, '" + pluginConfig.Name + "', has an invalid " + key + " definition, expected a positive 'int'")
+	}
+	return bound, nil
+}
+
 func newMemory(config *common.Config, pluginConfig *common.PluginConfig) (Cache, error) {
 	memory := &Memory{
 		config:       config,
 		pluginConfig: pluginConfig,
-		events:       make(map[string][]*common.Event),
+		entries:      make(map[string]*memoryEntry),
+		recent:       list.New(),
+		counters:     make(map[string]*memoryCounter),
+	}
+
+	var err error
+	if memory.maxLength, err = parseBound(pluginConfig, "max_length"); err != nil {
+		return nil, err
+	}
+
+	if memory.maxKeys, err = parseBound(pluginConfig, "max_keys"); err != nil {
+		return nil, err
 	}
 
+	if raw := pluginConfig.Config["ttl"]; raw != "" {
+		memory.ttl, err = time.ParseDuration(raw)
+		if err != nil || memory.ttl < 0 {
+			return nil, errors.New("configuration for the memory cache plugin, '" + pluginConfig.Name + "', has an invalid ttl, expected a 'duration' for example: '10m'")
+		}
+	}
+
+	// The sweeper always runs, as counters can expire even when events never do.
+	interval := maxSweepInterval
+	if memory.ttl > 0 && memory.ttl < interval {
+		interval = memory.ttl
+	}
+
+	if raw := pluginConfig.Config["sweep_interval"]; raw != "" {
+		interval, err = time.ParseDuration(raw)
+		if err != nil || interval <= 0 {
+			return nil, errors.New("configuration for the memory cache plugin, '" + pluginConfig.Name + "', has an invalid sweep_interval, expected a 'duration' for example: '1m'")
+		}
+	}
+
+	memory.stop = make(chan struct{})
+	memory.done = make(chan struct{})
+	go memory.sweeper(interval)
+
 	return memory, nil
 }
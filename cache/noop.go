@@ -4,6 +4,8 @@
 package cache
 
 import (
+	"time"
+
 	"github.com/Supernomad/protond/common"
 )
 
@@ -24,6 +26,36 @@ func (noop *Noop) Store(key string, event *common.Event) {
 	return
 }
 
+// GetSince will return an empty list of events.
+func (noop *Noop) GetSince(key string, since time.Time) []*common.Event {
+	return noop.events
+}
+
+// Len will return zero.
+func (noop *Noop) Len(key string) int {
+	return len(noop.events)
+}
+
+// Keys will return an empty list of keys.
+func (noop *Noop) Keys(prefix string) []string {
+	return []string{}
+}
+
+// Delete will noop the delete process of a cache plugin.
+func (noop *Noop) Delete(key string) {
+	return
+}
+
+// Incr will return n as if the counter had just been created.
+func (noop *Noop) Incr(key string, n int64, ttl time.Duration) int64 {
+	return n
+}
+
+// Close will noop the close process of a cache plugin.
+func (noop *Noop) Close() error {
+	return nil
+}
+
 // Name returns 'Noop'.
 func (noop *Noop) Name() string {
 	return noop.name
@@ -0,0 +1,157 @@
+// Copyright (c) 2017 Christian Saide <Supernomad>
+// Licensed under the MPL-2.0, for details see https://github.com/Supernomad/protond/blob/master/LICENSE
+
+package codec
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Supernomad/protond/common"
+)
+
+const (
+	// PlainCodec defines a codec that treats raw data as the 'message' field of an event.
+	PlainCodec = "plain"
+
+	// JSONCodec defines a codec that reads a single json document, and writes pretty printed json.
+	JSONCodec = "json"
+
+	// JSONLinesCodec defines a codec that reads and writes new line delimited json documents.
+	JSONLinesCodec = "json_lines"
+
+	// MsgpackCodec defines a codec that reads and writes msgpack encoded maps.
+	MsgpackCodec = "msgpack"
+
+	// CSVCodec defines a codec that reads and writes rows of comma separated values.
+	CSVCodec = "csv"
+
+	// LogfmtCodec defines a codec that reads and writes lines of space separated 'key=value' pairs.
+	LogfmtCodec = "logfmt"
+)
+
+// Codec is the interface that codecs must adhere to for converting events to and from the wire format of input and output plugins.
+type Codec interface {
+	// Decode should convert the supplied frame of raw data, such as a line, datagram, or request body, into the data of zero or more events, if there is an error during the process the returned error should be non-nil.
+	Decode([]byte) ([]map[string]interface{}, error)
+
+	// Encode should convert the supplied event into a frame of raw data, including any trailing delimiter the format requires.
+	Encode(*common.Event) ([]byte, error)
+
+	// Name returns the name of the codec.
+	Name() string
+
+	// FailureTag returns the tag added to events whose raw data the codec failed to decode.
+	FailureTag() string
+}
+
+// StreamReader is implemented by codecs whose values can contain new lines, so that input plugins reading a stream can read each value whole rather than splitting it on new lines.
+type StreamReader interface {
+	// ReadFrame should read the raw data of exactly one value from the supplied stream, if the stream can't be read or the value is malformed in a way that the next value can't be found the returned error should be non-nil.
+	ReadFrame(*bufio.Reader) ([]byte, error)
+}
+
+// ReadFrame reads the next frame from the supplied stream for the supplied codec, which is a single value for codecs that implement StreamReader and the next line for every other codec.
+func ReadFrame(c Codec, reader *bufio.Reader) ([]byte, error) {
+	if streamReader, ok := c.(StreamReader); ok {
+		return streamReader.ReadFrame(reader)
+	}
+	return reader.ReadBytes('\n')
+}
+
+// New generates a codec based on the passed in codec name and user defined plugin configuration, codec specific options are read from the 'codec_' prefixed keys of the plugin configuration.
+func New(codecName string, config *common.Config, pluginConfig *common.PluginConfig) (Codec, error) {
+	if pluginConfig == nil {
+		pluginConfig = &common.PluginConfig{Config: map[string]string{}}
+	}
+
+	switch codecName {
+	case PlainCodec:
+		return newPlain(config, pluginConfig)
+	case JSONCodec:
+		return newJSON(config, pluginConfig)
+	case JSONLinesCodec:
+		return newJSONLines(config, pluginConfig)
+	case MsgpackCodec:
+		return newMsgpack(config, pluginConfig)
+	case CSVCodec:
+		return newCSV(config, pluginConfig)
+	case LogfmtCodec:
+		return newLogfmt(config, pluginConfig)
+	}
+	return nil, errors.New("specified codec does not exist")
+}
+
+// Configured generates the codec named by the 'codec' key of the supplied plugin configuration, or the supplied default codec if the key is not set, the plugin configuration may be nil.
+func Configured(defaultCodec string, config *common.Config, pluginConfig *common.PluginConfig) (Codec, error) {
+	codecName := defaultCodec
+	if pluginConfig != nil && pluginConfig.Config["codec"] != "" {
+		codecName = pluginConfig.Config["codec"]
+	}
+
+	codec, err := New(codecName, config, pluginConfig)
+	if err != nil && pluginConfig != nil {
+		return nil, errors.New("configuration for the plugin, '" + pluginConfig.Name + "', has an invalid codec '" + codecName + "': " + err.Error())
+	}
+	return codec, err
+}
+
+// envelope returns the generic representation of the supplied event, matching the json representation of the event.
+func envelope(event *common.Event) map[string]interface{} {
+	ret := map[string]interface{}{
+		"timestamp": event.Timestamp.Format(time.RFC3339Nano),
+		"input":     event.Input,
+		"data":      event.Data,
+	}
+	if len(event.Tags) > 0 {
+		ret["tags"] = event.Tags
+	}
+	return ret
+}
+
+// wrap returns the supplied decoded value as event data, values that are not objects such as batched arrays are wrapped in the 'message' field.
+func wrap(value interface{}) map[string]interface{} {
+	if data, ok := value.(map[string]interface{}); ok {
+		return data
+	}
+	return map[string]interface{}{"message": value}
+}
+
+// formatValue returns the textual representation of the supplied value for the text based codecs, values without a natural textual representation are formatted as json.
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	}
+
+	buf, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(buf)
+}
+
+func sortedKeys(data map[string]interface{}) []string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,301 @@
+// Copyright (c) 2017 Christian Saide <Supernomad>
+// Licensed under the MPL-2.0, for details see https://github.com/Supernomad/protond/blob/master/LICENSE
+
+package codec
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Supernomad/protond/common"
+)
+
+var testEvent = &common.Event{
+	Timestamp: time.Date(2017, time.March, 1, 22, 14, 15, 0, time.UTC),
+	Input:     "test",
+	Tags:      []string{"a", "b"},
+	Data: map[string]interface{}{
+		"message": "hello world",
+		"status":  float64(200),
+		"nested":  map[string]interface{}{"ok": true},
+	},
+}
+
+func newTestCodec(t *testing.T, codecName string, config map[string]string) Codec {
+	codec, err := New(codecName, nil, &common.PluginConfig{Name: "test", Config: config})
+	if err != nil {
+		t.Fatalf("failed to create the %s codec: %s", codecName, err.Error())
+	}
+	if codec.Name() != codecName {
+		t.Fatal("Something is wrong name wasn't handled properly.")
+	}
+	return codec
+}
+
+func TestNonExistentCodec(t *testing.T) {
+	codec, err := New("doesn't exist", nil, nil)
+	if err == nil || codec != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+
+	codec, err = Configured(PlainCodec, nil, &common.PluginConfig{Name: "test", Config: map[string]string{"codec": "doesn't exist"}})
+	if err == nil || codec != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+}
+
+func TestConfigured(t *testing.T) {
+	codec, err := Configured(JSONCodec, nil, nil)
+	if err != nil || codec.Name() != JSONCodec {
+		t.Fatal("Configured did not fall back to the default codec.")
+	}
+
+	codec, err = Configured(JSONCodec, nil, &common.PluginConfig{Name: "test", Config: map[string]string{"codec": LogfmtCodec}})
+	if err != nil || codec.Name() != LogfmtCodec {
+		t.Fatal("Configured did not use the configured codec.")
+	}
+}
+
+func TestPlain(t *testing.T) {
+	plain := newTestCodec(t, PlainCodec, nil)
+
+	records, err := plain.Decode([]byte("hello\r\n"))
+	if err != nil || len(records) != 1 || records[0]["message"] != "hello" {
+		t.Fatalf("plain codec improperly decoded a frame: %v", records)
+	}
+
+	buf, err := plain.Encode(testEvent)
+	if err != nil || string(buf) != "hello world\n" {
+		t.Fatalf("plain codec improperly encoded an event: %q", buf)
+	}
+
+	buf, err = plain.Encode(&common.Event{Input: "test", Data: map[string]interface{}{"status": float64(200)}})
+	if err != nil || !strings.Contains(string(buf), `"status":200`) {
+		t.Fatalf("plain codec did not encode an event without a message as json: %q", buf)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	j := newTestCodec(t, JSONCodec, nil)
+	if j.FailureTag() != JSONFailureTag {
+		t.Fatal("Something is very very wrong.")
+	}
+
+	records, err := j.Decode([]byte(`{"a": 1, "b": [1, 2]}`))
+	if err != nil || len(records) != 1 || records[0]["a"] != float64(1) {
+		t.Fatalf("json codec improperly decoded an object: %v", records)
+	}
+
+	records, err = j.Decode([]byte(`[1, 2]`))
+	if err != nil || len(records) != 1 || !reflect.DeepEqual(records[0]["message"], []interface{}{float64(1), float64(2)}) {
+		t.Fatalf("json codec improperly wrapped an array: %v", records)
+	}
+
+	if _, err := j.Decode([]byte(`{"a": `)); err == nil {
+		t.Fatal("json codec decoded invalid json without error.")
+	}
+
+	buf, err := j.Encode(testEvent)
+	if err != nil || string(buf) != testEvent.String(true)+"\n" {
+		t.Fatalf("json codec improperly encoded an event: %s", buf)
+	}
+}
+
+func TestJSONLines(t *testing.T) {
+	j := newTestCodec(t, JSONLinesCodec, nil)
+
+	records, err := j.Decode([]byte("{\"a\": 1}\n\n{\"a\": 2}\r\n\"text\"\n"))
+	if err != nil || len(records) != 3 || records[0]["a"] != float64(1) || records[1]["a"] != float64(2) || records[2]["message"] != "text" {
+		t.Fatalf("json_lines codec improperly decoded a frame: %v", records)
+	}
+
+	if _, err := j.Decode([]byte("{\"a\": 1}\nnope\n")); err == nil {
+		t.Fatal("json_lines codec decoded invalid json without error.")
+	}
+
+	buf, err := j.Encode(testEvent)
+	if err != nil || string(buf) != testEvent.String(false)+"\n" {
+		t.Fatalf("json_lines codec improperly encoded an event: %s", buf)
+	}
+}
+
+func TestMsgpack(t *testing.T) {
+	msgpack := newTestCodec(t, MsgpackCodec, nil)
+	if msgpack.FailureTag() != MsgpackFailureTag {
+		t.Fatal("Something is very very wrong.")
+	}
+
+	values := map[string]interface{}{
+		"nil":      nil,
+		"true":     true,
+		"false":    false,
+		"fixint":   int64(7),
+		"negfix":   int64(-7),
+		"int8":     int64(-100),
+		"int16":    int64(-30000),
+		"int32":    int64(-2000000000),
+		"int64":    int64(-9000000000),
+		"uint8":    int64(200),
+		"uint16":   int64(60000),
+		"uint32":   int64(4000000000),
+		"uint64":   int64(9000000000),
+		"float":    1.5,
+		"fixstr":   "short",
+		"str8":     strings.Repeat("a", 100),
+		"str16":    strings.Repeat("b", 1000),
+		"bin":      []byte{1, 2, 3},
+		"array":    []interface{}{int64(1), "two", 3.5},
+		"array16":  make([]interface{}, 20),
+		"map":      map[string]interface{}{"nested": map[string]interface{}{"deep": "value"}},
+		"strslice": []string{"x", "y"},
+	}
+
+	buf, err := msgpack.Encode(&common.Event{Timestamp: testEvent.Timestamp, Input: "test", Data: values})
+	if err != nil {
+		t.Fatalf("msgpack codec failed to encode an event: %s", err.Error())
+	}
+
+	records, err := msgpack.Decode(append(buf, buf...))
+	if err != nil || len(records) != 2 {
+		t.Fatalf("msgpack codec failed to decode a frame: %v", err)
+	}
+	if records[0]["timestamp"] != "2017-03-01T22:14:15Z" || records[0]["input"] != "test" {
+		t.Fatalf("msgpack codec improperly encoded the event envelope: %v", records[0])
+	}
+
+	data := records[1]["data"].(map[string]interface{})
+	values["strslice"] = []interface{}{"x", "y"}
+	for key, expected := range values {
+		if !reflect.DeepEqual(data[key], expected) {
+			t.Fatalf("msgpack codec improperly round tripped '%s', expected %#v got %#v", key, expected, data[key])
+		}
+	}
+
+	// A fixext 8 timestamp, 1 second after the epoch plus 500 nanoseconds.
+	records, err = msgpack.Decode([]byte{0x81, 0xa1, 't', 0xd7, 0xff, 0x00, 0x00, 0x07, 0xd0, 0x00, 0x00, 0x00, 0x01})
+	if err != nil || !records[0]["t"].(time.Time).Equal(time.Unix(1, 500).UTC()) {
+		t.Fatalf("msgpack codec improperly decoded a timestamp: %v %v", records, err)
+	}
+
+	records, err = msgpack.Decode([]byte{0xa2, 'h', 'i'})
+	if err != nil || records[0]["message"] != "hi" {
+		t.Fatalf("msgpack codec improperly wrapped a value that isn't a map: %v", records)
+	}
+
+	for _, invalid := range [][]byte{{0xc1}, {0x82, 0xa1, 'a'}, {0xdb, 0xff, 0xff, 0xff, 0xff}, {0xdd, 0xff, 0xff, 0xff, 0xff}, {0xd4, 0x01, 0x00}} {
+		if _, err := msgpack.Decode(invalid); err == nil {
+			t.Fatalf("msgpack codec decoded invalid data without error: %x", invalid)
+		}
+	}
+}
+
+func TestReadFrame(t *testing.T) {
+	msgpack := newTestCodec(t, MsgpackCodec, nil)
+
+	// Every value is written back to back, and each of them contains a new line byte.
+	buf, err := msgpack.Encode(&common.Event{Timestamp: testEvent.Timestamp, Input: "test", Data: map[string]interface{}{"count": 10, "message": "multi\nline", "bin": []byte{'\n'}, "array": []interface{}{10, 10.5}}})
+	if err != nil {
+		t.Fatalf("msgpack codec failed to encode an event: %s", err.Error())
+	}
+	frames := [][]byte{buf, {0x81, 0xa1, 't', 0xd7, 0xff, 0x00, 0x00, 0x07, 0xd0, 0x00, 0x00, 0x00, 0x0a}, {0x0a}}
+
+	reader := bufio.NewReader(bytes.NewReader(bytes.Join(frames, nil)))
+	for _, expected := range frames {
+		frame, err := ReadFrame(msgpack, reader)
+		if err != nil || !bytes.Equal(frame, expected) {
+			t.Fatalf("msgpack codec did not read a whole value from a stream, expected %x got %x: %v", expected, frame, err)
+		}
+	}
+	if _, err := ReadFrame(msgpack, reader); err != io.EOF {
+		t.Fatalf("msgpack codec did not return io.EOF at the end of a stream: %v", err)
+	}
+
+	for _, invalid := range [][]byte{{0xc1}, {0x82, 0xa1, 'a'}, {0xdb, 0xff, 0xff, 0xff, 0xff}} {
+		if _, err := ReadFrame(msgpack, bufio.NewReader(bytes.NewReader(invalid))); err == nil || err == io.EOF {
+			t.Fatalf("msgpack codec read invalid data from a stream without error: %x", invalid)
+		}
+	}
+
+	reader = bufio.NewReader(strings.NewReader("first\nsecond\n"))
+	for _, expected := range []string{"first\n", "second\n"} {
+		frame, err := ReadFrame(newTestCodec(t, PlainCodec, nil), reader)
+		if err != nil || string(frame) != expected {
+			t.Fatalf("plain codec did not read a line from a stream, expected '%s' got '%s'", expected, frame)
+		}
+	}
+}
+
+func TestCSV(t *testing.T) {
+	if _, err := New(CSVCodec, nil, &common.PluginConfig{Name: "test", Config: map[string]string{"codec_separator": "ab"}}); err == nil {
+		t.Fatal("csv codec did not throw an error when configured with an invalid separator.")
+	}
+
+	csv := newTestCodec(t, CSVCodec, map[string]string{"codec_columns": "message, status, nested.ok"})
+	if csv.FailureTag() != CSVFailureTag {
+		t.Fatal("Something is very very wrong.")
+	}
+
+	records, err := csv.Decode([]byte("\"hello, world\",200,true,extra\nbye,404\n"))
+	if err != nil || len(records) != 2 {
+		t.Fatalf("csv codec failed to decode a frame: %v", err)
+	}
+	if records[0]["message"] != "hello, world" || records[0]["status"] != "200" || records[0]["nested.ok"] != "true" || records[0]["column4"] != "extra" || records[1]["message"] != "bye" {
+		t.Fatalf("csv codec improperly decoded a frame: %v", records)
+	}
+
+	if _, err := csv.Decode([]byte("\"unterminated\n")); err == nil {
+		t.Fatal("csv codec decoded an invalid row without error.")
+	}
+
+	buf, err := csv.Encode(testEvent)
+	if err != nil || string(buf) != "hello world,200,true\n" {
+		t.Fatalf("csv codec improperly encoded an event: %q", buf)
+	}
+
+	tsv := newTestCodec(t, CSVCodec, map[string]string{"codec_separator": `\t`})
+	buf, err = tsv.Encode(testEvent)
+	if err != nil || string(buf) != "hello world\t\"{\"\"ok\"\":true}\"\t200\n" {
+		t.Fatalf("csv codec improperly encoded an event without columns: %q", buf)
+	}
+
+	records, err = tsv.Decode([]byte("a\tb\n"))
+	if err != nil || records[0]["column1"] != "a" || records[0]["column2"] != "b" {
+		t.Fatalf("csv codec improperly decoded a frame without columns: %v", records)
+	}
+}
+
+func TestLogfmt(t *testing.T) {
+	logfmt := newTestCodec(t, LogfmtCodec, nil)
+	if logfmt.FailureTag() != LogfmtFailureTag {
+		t.Fatal("Something is very very wrong.")
+	}
+
+	records, err := logfmt.Decode([]byte("level=info msg=\"hello \\\"world\\\"\" debug status=200\n\nlevel=warn empty=\n"))
+	if err != nil || len(records) != 2 {
+		t.Fatalf("logfmt codec failed to decode a frame: %v", err)
+	}
+	if records[0]["level"] != "info" || records[0]["msg"] != `hello "world"` || records[0]["debug"] != true || records[0]["status"] != "200" || records[1]["level"] != "warn" || records[1]["empty"] != "" {
+		t.Fatalf("logfmt codec improperly decoded a frame: %v", records)
+	}
+
+	for _, invalid := range []string{"=value", `key="unterminated`, `key="bad \q"`} {
+		if _, err := logfmt.Decode([]byte(invalid)); err == nil {
+			t.Fatalf("logfmt codec decoded an invalid line without error: %s", invalid)
+		}
+	}
+
+	buf, err := logfmt.Encode(testEvent)
+	if err != nil || string(buf) != "timestamp=2017-03-01T22:14:15Z input=test tags=a,b message=\"hello world\" nested.ok=true status=200\n" {
+		t.Fatalf("logfmt codec improperly encoded an event: %q", buf)
+	}
+
+	records, err = logfmt.Decode(buf)
+	if err != nil || records[0]["message"] != "hello world" || records[0]["nested.ok"] != "true" {
+		t.Fatalf("logfmt codec did not round trip an event: %v", records)
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2017 Christian Saide <Supernomad>
+// Licensed under the MPL-2.0, for details see https://github.com/Supernomad/protond/blob/master/LICENSE
+
+package codec
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Supernomad/protond/common"
+)
+
+// CSVFailureTag is the tag added to events whose raw data could not be decoded by the csv codec.
+const CSVFailureTag = "_csvparsefailure"
+
+// CSV is a struct representing the comma separated values codec.
+type CSV struct {
+	config       *common.Config
+	pluginConfig *common.PluginConfig
+	columns      []string
+	separator    rune
+}
+
+// column returns the name of the column at the supplied index, unnamed columns are named 'column1', 'column2', and so on.
+func (c *CSV) column(i int) string {
+	if i < len(c.columns) {
+		return c.columns[i]
+	}
+	return "column" + strconv.Itoa(i+1)
+}
+
+// Decode will parse every row of the supplied frame into the data of a separate event, keyed by the configured column names.
+func (c *CSV) Decode(frame []byte) ([]map[string]interface{}, error) {
+	reader := csv.NewReader(bytes.NewReader(frame))
+	reader.Comma = c.separator
+	reader.FieldsPerRecord = -1
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]map[string]interface{}, 0, len(records))
+	for _, record := range records {
+		data := make(map[string]interface{}, len(record))
+		for i, value := range record {
+			data[c.column(i)] = value
+		}
+		ret = append(ret, data)
+	}
+	return ret, nil
+}
+
+// Encode will return a single row holding the configured columns of the supplied event, or every top level field in sorted order if no columns are configured.
+func (c *CSV) Encode(event *common.Event) ([]byte, error) {
+	columns := c.columns
+	if len(columns) == 0 {
+		columns = sortedKeys(event.Data)
+	}
+
+	record := make([]string, len(columns))
+	for i, column := range columns {
+		value, _ := event.Field(column)
+		record[i] = formatValue(value)
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Comma = c.separator
+	if err := writer.Write(record); err != nil {
+		return nil, err
+	}
+	writer.Flush()
+
+	return buf.Bytes(), writer.Error()
+}
+
+// Name returns 'csv'.
+func (c *CSV) Name() string {
+	return CSVCodec
+}
+
+// FailureTag returns the CSVFailureTag.
+func (c *CSV) FailureTag() string {
+	return CSVFailureTag
+}
+
+func newCSV(config *common.Config, pluginConfig *common.PluginConfig) (Codec, error) {
+	c := &CSV{
+		config:       config,
+		pluginConfig: pluginConfig,
+		columns:      make([]string, 0),
+		separator:    ',',
+	}
+
+	if raw := pluginConfig.Config["codec_columns"]; raw != "" {
+		for _, column := range strings.Split(raw, ",") {
+			c.columns = append(c.columns, strings.TrimSpace(column))
+		}
+	}
+
+	if raw := pluginConfig.Config["codec_separator"]; raw != "" {
+		if raw == `\t` {
+			raw = "\t"
+		}
+
+		separator, size := utf8.DecodeRuneInString(raw)
+		if size != len(raw) || separator == '"' || separator == '\r' || separator == '\n' || separator == utf8.RuneError {
+			return nil, errors.New("the csv codec has an invalid codec_separator definition, expected a single character other than a quote or new line")
+		}
+		c.separator = separator
+	}
+
+	return c, nil
+}
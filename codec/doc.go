@@ -0,0 +1,28 @@
+// Copyright (c) 2017 Christian Saide <Supernomad>
+// Licensed under the MPL-2.0, for details see https://github.com/Supernomad/protond/blob/master/LICENSE
+
+/*
+Package codec contains the interfaces, structs, and logic that convert events to and from the wire format of protonds input and output plugins.
+
+Every input and output plugin that reads or writes raw data selects its codec with the 'codec' configuration key, and falls back to the format it has always used when the key isn't set.
+Input plugins decode each frame they receive, such as a line, a datagram, or a request body, into one or more events, frames that fail to decode are kept in the 'message' field of a single event tagged with the failure tag of the codec.
+Output plugins write the encoded form of each event, which includes any trailing delimiter the format requires.
+
+Protond currently implements the following codecs:
+  - Plain
+    - Decodes a frame into the 'message' field, and encodes the 'message' field of an event followed by a new line.
+  - JSON
+    - Decodes a frame as a single json document, and encodes events as pretty printed json, json values that are not objects such as batched arrays are wrapped in the 'message' field.
+  - JSON Lines
+    - Decodes each line of a frame as a separate json document, and encodes events as compact json followed by a new line.
+  - Msgpack
+    - Decodes each msgpack value in a frame as a separate event, and encodes events as msgpack maps with the same structure as their json representation, the msgpack timestamp extension is decoded into a timestamp.
+      Msgpack values can contain new line bytes, so stream based inputs read each value whole instead of a line, and the File input plugin, which can only read lines, rejects the codec.
+  - CSV
+    - Decodes each row of a frame as a separate event, keyed by the comma separated column names in 'codec_columns' or 'column1', 'column2', and so on, and encodes the same columns of an event as a row, or every top level field in sorted order if no columns are configured.
+      The 'codec_separator' configuration key changes the separator from a comma, for example '\t' or ';'.
+      Quoted values containing new lines are only decoded whole by inputs that receive a complete frame at a time, such as UDP, UnixGram, and Http, stream based inputs split them into separate lines.
+  - Logfmt
+    - Decodes each line of a frame of 'key=value' pairs as a separate event, and encodes the timestamp, input, tags, and fields of an event as a line of pairs, nested fields are flattened into '.' separated keys.
+*/
+package codec
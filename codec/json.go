@@ -0,0 +1,98 @@
+// Copyright (c) 2017 Christian Saide <Supernomad>
+// Licensed under the MPL-2.0, for details see https://github.com/Supernomad/protond/blob/master/LICENSE
+
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/Supernomad/protond/common"
+)
+
+// JSONFailureTag is the tag added to events whose raw data could not be decoded by the json or json_lines codecs.
+const JSONFailureTag = "_jsonparsefailure"
+
+// JSON is a struct representing the json codec.
+type JSON struct {
+	config       *common.Config
+	pluginConfig *common.PluginConfig
+}
+
+// Decode will parse the supplied frame as a single json document, documents that are not objects such as batched arrays are wrapped in the 'message' field.
+func (j *JSON) Decode(frame []byte) ([]map[string]interface{}, error) {
+	var raw interface{}
+	if err := json.Unmarshal(frame, &raw); err != nil {
+		return nil, err
+	}
+	return []map[string]interface{}{wrap(raw)}, nil
+}
+
+// Encode will return the pretty printed json representation of the supplied event followed by a new line.
+func (j *JSON) Encode(event *common.Event) ([]byte, error) {
+	buf, err := json.MarshalIndent(event, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+	return append(buf, '\n'), nil
+}
+
+// Name returns 'json'.
+func (j *JSON) Name() string {
+	return JSONCodec
+}
+
+// FailureTag returns the JSONFailureTag.
+func (j *JSON) FailureTag() string {
+	return JSONFailureTag
+}
+
+func newJSON(config *common.Config, pluginConfig *common.PluginConfig) (Codec, error) {
+	return &JSON{config: config, pluginConfig: pluginConfig}, nil
+}
+
+// JSONLines is a struct representing the new line delimited json codec.
+type JSONLines struct {
+	config       *common.Config
+	pluginConfig *common.PluginConfig
+}
+
+// Decode will parse every non blank line of the supplied frame as a separate json document, documents that are not objects are wrapped in the 'message' field.
+func (j *JSONLines) Decode(frame []byte) ([]map[string]interface{}, error) {
+	ret := make([]map[string]interface{}, 0, 1)
+	for _, line := range bytes.Split(frame, []byte("\n")) {
+		if line = bytes.TrimSpace(line); len(line) == 0 {
+			continue
+		}
+
+		var raw interface{}
+		if err := json.Unmarshal(line, &raw); err != nil {
+			return nil, err
+		}
+		ret = append(ret, wrap(raw))
+	}
+	return ret, nil
+}
+
+// Encode will return the compact json representation of the supplied event followed by a new line.
+func (j *JSONLines) Encode(event *common.Event) ([]byte, error) {
+	buf, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	return append(buf, '\n'), nil
+}
+
+// Name returns 'json_lines'.
+func (j *JSONLines) Name() string {
+	return JSONLinesCodec
+}
+
+// FailureTag returns the JSONFailureTag.
+func (j *JSONLines) FailureTag() string {
+	return JSONFailureTag
+}
+
+func newJSONLines(config *common.Config, pluginConfig *common.PluginConfig) (Codec, error) {
+	return &JSONLines{config: config, pluginConfig: pluginConfig}, nil
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2017 Christian Saide <Supernomad>
+// Licensed under the MPL-2.0, for details see https://github.com/Supernomad/protond/blob/master/LICENSE
+
+package codec
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Supernomad/protond/common"
+)
+
+// LogfmtFailureTag is the tag added to events whose raw data could not be decoded by the logfmt codec.
+const LogfmtFailureTag = "_logfmtparsefailure"
+
+// Logfmt is a struct representing the logfmt codec.
+type Logfmt struct {
+	config       *common.Config
+	pluginConfig *common.PluginConfig
+}
+
+// decodeLine parses a single line of 'key=value' pairs, values may be double quoted with go style escapes and keys without a value are set to true.
+func decodeLine(line string) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+	for line = strings.TrimLeft(line, " \t"); line != ""; line = strings.TrimLeft(line, " \t") {
+		end := strings.IndexAny(line, "= \t")
+		if end == 0 {
+			return nil, errors.New("logfmt line has a pair without a key")
+		}
+		if end < 0 {
+			end = len(line)
+		}
+
+		key := line[:end]
+		line = line[end:]
+		if !strings.HasPrefix(line, "=") {
+			data[key] = true
+			continue
+		}
+		line = line[1:]
+
+		if !strings.HasPrefix(line, `"`) {
+			end = strings.IndexAny(line, " \t")
+			if end < 0 {
+				end = len(line)
+			}
+			data[key] = line[:end]
+			line = line[end:]
+			continue
+		}
+
+		// Find the closing quote, skipping escaped characters.
+		end = 1
+		for end < len(line) && line[end] != '"' {
+			if line[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(line) {
+			return nil, errors.New("logfmt line has an unterminated quoted value for the key '" + key + "'")
+		}
+
+		value, err := strconv.Unquote(line[:end+1])
+		if err != nil {
+			return nil, errors.New("logfmt line has an invalid quoted value for the key '" + key + "'")
+		}
+		data[key] = value
+		line = line[end+1:]
+	}
+	return data, nil
+}
+
+// Decode will parse every non blank line of the supplied frame into the data of a separate event.
+func (logfmt *Logfmt) Decode(frame []byte) ([]map[string]interface{}, error) {
+	ret := make([]map[string]interface{}, 0, 1)
+	for _, line := range strings.Split(string(frame), "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+
+		data, err := decodeLine(line)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, data)
+	}
+	return ret, nil
+}
+
+func encodePair(buf *bytes.Buffer, key string, value interface{}) {
+	// Nested objects are flattened into '.' separated keys.
+	if obj, ok := value.(map[string]interface{}); ok {
+		for _, sub := range sortedKeys(obj) {
+			encodePair(buf, key+"."+sub, obj[sub])
+		}
+		return
+	}
+
+	if buf.Len() > 0 {
+		buf.WriteByte(' ')
+	}
+
+	text := formatValue(value)
+	buf.WriteString(key)
+	buf.WriteByte('=')
+	if text == "" || strings.ContainsAny(text, " \t\r\n=\"\\") {
+		text = strconv.Quote(text)
+	}
+	buf.WriteString(text)
+}
+
+// Encode will return a single line holding the timestamp, input, and tags of the supplied event followed by every field of the event in sorted order.
+func (logfmt *Logfmt) Encode(event *common.Event) ([]byte, error) {
+	var buf bytes.Buffer
+	encodePair(&buf, "timestamp", event.Timestamp.Format(time.RFC3339Nano))
+	encodePair(&buf, "input", event.Input)
+	if len(event.Tags) > 0 {
+		encodePair(&buf, "tags", strings.Join(event.Tags, ","))
+	}
+
+	for _, key := range sortedKeys(event.Data) {
+		encodePair(&buf, key, event.Data[key])
+	}
+
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// Name returns 'logfmt'.
+func (logfmt *Logfmt) Name() string {
+	return LogfmtCodec
+}
+
+// FailureTag returns the LogfmtFailureTag.
+func (logfmt *Logfmt) FailureTag() string {
+	return LogfmtFailureTag
+}
+
+func newLogfmt(config *common.Config, pluginConfig *common.PluginConfig) (Codec, error) {
+	return &Logfmt{config: config, pluginConfig: pluginConfig}, nil
+}
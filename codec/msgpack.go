@@ -0,0 +1,561 @@
+// Copyright (c) 2017 Christian Saide <Supernomad>
+// Licensed under the MPL-2.0, for details see https://github.com/Supernomad/protond/blob/master/LICENSE
+
+package codec
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/Supernomad/protond/common"
+)
+
+const (
+	// MsgpackFailureTag is the tag added to events whose raw data could not be decoded by the msgpack codec.
+	MsgpackFailureTag = "_msgpackparsefailure"
+
+	msgpackMaxDepth      = 64
+	msgpackTimestampType = -1
+)
+
+var errMsgpackShort = errors.New("msgpack data ended unexpectedly")
+
+// Msgpack is a struct representing the msgpack codec.
+type Msgpack struct {
+	config       *common.Config
+	pluginConfig *common.PluginConfig
+}
+
+type msgpackEncoder struct {
+	buf     bytes.Buffer
+	scratch [9]byte
+}
+
+// header writes the supplied type byte followed by n encoded as a big endian integer of the supplied size.
+func (enc *msgpackEncoder) header(code byte, n uint64, size int) {
+	enc.scratch[0] = code
+	switch size {
+	case 1:
+		enc.scratch[1] = byte(n)
+	case 2:
+		binary.BigEndian.PutUint16(enc.scratch[1:], uint16(n))
+	case 4:
+		binary.BigEndian.PutUint32(enc.scratch[1:], uint32(n))
+	case 8:
+		binary.BigEndian.PutUint64(enc.scratch[1:], n)
+	}
+	enc.buf.Write(enc.scratch[:size+1])
+}
+
+// length writes the header of a string, binary, array, or map of the supplied length, fix is the type byte of the fixed size variant when there is one, and codes holds the type bytes of the 8, 16, and 32 bit variants.
+func (enc *msgpackEncoder) length(n int, fix byte, fixMax int, codes [3]byte) {
+	switch {
+	case n <= fixMax:
+		enc.buf.WriteByte(fix | byte(n))
+	case n <= math.MaxUint8 && codes[0] != 0:
+		enc.header(codes[0], uint64(n), 1)
+	case n <= math.MaxUint16:
+		enc.header(codes[1], uint64(n), 2)
+	default:
+		enc.header(codes[2], uint64(n), 4)
+	}
+}
+
+func (enc *msgpackEncoder) encodeString(s string) {
+	enc.length(len(s), 0xa0, 31, [3]byte{0xd9, 0xda, 0xdb})
+	enc.buf.WriteString(s)
+}
+
+func (enc *msgpackEncoder) encodeUint(n uint64) {
+	switch {
+	case n <= 0x7f:
+		enc.buf.WriteByte(byte(n))
+	case n <= math.MaxUint8:
+		enc.header(0xcc, n, 1)
+	case n <= math.MaxUint16:
+		enc.header(0xcd, n, 2)
+	case n <= math.MaxUint32:
+		enc.header(0xce, n, 4)
+	default:
+		enc.header(0xcf, n, 8)
+	}
+}
+
+func (enc *msgpackEncoder) encodeInt(n int64) {
+	switch {
+	case n >= 0:
+		enc.encodeUint(uint64(n))
+	case n >= -32:
+		enc.buf.WriteByte(byte(n))
+	case n >= math.MinInt8:
+		enc.header(0xd0, uint64(n), 1)
+	case n >= math.MinInt16:
+		enc.header(0xd1, uint64(n), 2)
+	case n >= math.MinInt32:
+		enc.header(0xd2, uint64(n), 4)
+	default:
+		enc.header(0xd3, uint64(n), 8)
+	}
+}
+
+func (enc *msgpackEncoder) encode(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		enc.buf.WriteByte(0xc0)
+	case bool:
+		if v {
+			enc.buf.WriteByte(0xc3)
+		} else {
+			enc.buf.WriteByte(0xc2)
+		}
+	case string:
+		enc.encodeString(v)
+	case []byte:
+		enc.length(len(v), 0, -1, [3]byte{0xc4, 0xc5, 0xc6})
+		enc.buf.Write(v)
+	case int:
+		enc.encodeInt(int64(v))
+	case int8:
+		enc.encodeInt(int64(v))
+	case int16:
+		enc.encodeInt(int64(v))
+	case int32:
+		enc.encodeInt(int64(v))
+	case int64:
+		enc.encodeInt(v)
+	case uint:
+		enc.encodeUint(uint64(v))
+	case uint8:
+		enc.encodeUint(uint64(v))
+	case uint16:
+		enc.encodeUint(uint64(v))
+	case uint32:
+		enc.encodeUint(uint64(v))
+	case uint64:
+		enc.encodeUint(v)
+	case float32:
+		enc.header(0xca, uint64(math.Float32bits(v)), 4)
+	case float64:
+		enc.header(0xcb, math.Float64bits(v), 8)
+	case time.Time:
+		enc.encodeString(v.Format(time.RFC3339Nano))
+	case []string:
+		enc.length(len(v), 0x90, 15, [3]byte{0, 0xdc, 0xdd})
+		for _, s := range v {
+			enc.encodeString(s)
+		}
+	case []interface{}:
+		enc.length(len(v), 0x90, 15, [3]byte{0, 0xdc, 0xdd})
+		for _, item := range v {
+			if err := enc.encode(item); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		enc.length(len(v), 0x80, 15, [3]byte{0, 0xde, 0xdf})
+		for _, key := range sortedKeys(v) {
+			enc.encodeString(key)
+			if err := enc.encode(v[key]); err != nil {
+				return err
+			}
+		}
+	default:
+		// Any other value is converted to its generic json representation, which only holds the types handled above.
+		buf, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+
+		var generic interface{}
+		if err := json.Unmarshal(buf, &generic); err != nil {
+			return err
+		}
+		return enc.encode(generic)
+	}
+	return nil
+}
+
+type msgpackDecoder struct {
+	buf []byte
+	pos int
+}
+
+func (dec *msgpackDecoder) next(n int) ([]byte, error) {
+	if n < 0 || len(dec.buf)-dec.pos < n {
+		return nil, errMsgpackShort
+	}
+
+	ret := dec.buf[dec.pos : dec.pos+n]
+	dec.pos += n
+	return ret, nil
+}
+
+// uint reads a big endian unsigned integer of the supplied size.
+func (dec *msgpackDecoder) uint(size int) (uint64, error) {
+	b, err := dec.next(size)
+	if err != nil {
+		return 0, err
+	}
+
+	switch size {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+// length reads a length of the supplied size, rejecting lengths that can't possibly fit in the remaining data.
+func (dec *msgpackDecoder) length(size int) (int, error) {
+	n, err := dec.uint(size)
+	if err != nil {
+		return 0, err
+	}
+	if n > uint64(len(dec.buf)-dec.pos) {
+		return 0, errMsgpackShort
+	}
+	return int(n), nil
+}
+
+func (dec *msgpackDecoder) decodeString(n int) (interface{}, error) {
+	b, err := dec.next(n)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (dec *msgpackDecoder) decodeArray(n int, depth int) (interface{}, error) {
+	if n > len(dec.buf)-dec.pos {
+		return nil, errMsgpackShort
+	}
+
+	ret := make([]interface{}, n)
+	for i := range ret {
+		item, err := dec.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		ret[i] = item
+	}
+	return ret, nil
+}
+
+func (dec *msgpackDecoder) decodeMap(n int, depth int) (interface{}, error) {
+	if n > len(dec.buf)-dec.pos {
+		return nil, errMsgpackShort
+	}
+
+	ret := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		key, err := dec.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := dec.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		if s, ok := key.(string); ok {
+			ret[s] = value
+		} else {
+			ret[formatValue(key)] = value
+		}
+	}
+	return ret, nil
+}
+
+// decodeExt decodes an extension value of the supplied length, only the timestamp extension is supported.
+func (dec *msgpackDecoder) decodeExt(n int) (interface{}, error) {
+	typ, err := dec.next(1)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := dec.next(n)
+	if err != nil {
+		return nil, err
+	}
+
+	if int8(typ[0]) != msgpackTimestampType {
+		return nil, errors.New("msgpack extension type " + strconv.Itoa(int(int8(typ[0]))) + " is not supported")
+	}
+
+	switch n {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(b)), 0).UTC(), nil
+	case 8:
+		raw := binary.BigEndian.Uint64(b)
+		return time.Unix(int64(raw&0x3ffffffff), int64(raw>>34)).UTC(), nil
+	case 12:
+		return time.Unix(int64(binary.BigEndian.Uint64(b[4:])), int64(binary.BigEndian.Uint32(b))).UTC(), nil
+	}
+	return nil, errors.New("msgpack timestamp has an invalid length")
+}
+
+func (dec *msgpackDecoder) decode(depth int) (interface{}, error) {
+	if depth > msgpackMaxDepth {
+		return nil, errors.New("msgpack data is nested too deeply")
+	}
+
+	b, err := dec.next(1)
+	if err != nil {
+		return nil, err
+	}
+
+	code := b[0]
+	switch {
+	case code <= 0x7f:
+		return int64(code), nil
+	case code >= 0xe0:
+		return int64(int8(code)), nil
+	case code&0xf0 == 0x80:
+		return dec.decodeMap(int(code&0x0f), depth)
+	case code&0xf0 == 0x90:
+		return dec.decodeArray(int(code&0x0f), depth)
+	case code&0xe0 == 0xa0:
+		return dec.decodeString(int(code & 0x1f))
+	}
+
+	switch code {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := dec.length(1 << (code - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		b, err := dec.next(n)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), b...), nil
+	case 0xc7, 0xc8, 0xc9:
+		n, err := dec.length(1 << (code - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return dec.decodeExt(n)
+	case 0xca:
+		n, err := dec.uint(4)
+		return float64(math.Float32frombits(uint32(n))), err
+	case 0xcb:
+		n, err := dec.uint(8)
+		return math.Float64frombits(n), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n, err := dec.uint(1 << (code - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		if n > math.MaxInt64 {
+			return n, nil
+		}
+		return int64(n), nil
+	case 0xd0:
+		n, err := dec.uint(1)
+		return int64(int8(n)), err
+	case 0xd1:
+		n, err := dec.uint(2)
+		return int64(int16(n)), err
+	case 0xd2:
+		n, err := dec.uint(4)
+		return int64(int32(n)), err
+	case 0xd3:
+		n, err := dec.uint(8)
+		return int64(n), err
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return dec.decodeExt(1 << (code - 0xd4))
+	case 0xd9, 0xda, 0xdb:
+		n, err := dec.length(1 << (code - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return dec.decodeString(n)
+	case 0xdc, 0xdd:
+		n, err := dec.uint(2 << (code - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		if n > uint64(len(dec.buf)-dec.pos) {
+			return nil, errMsgpackShort
+		}
+		return dec.decodeArray(int(n), depth)
+	case 0xde, 0xdf:
+		n, err := dec.uint(2 << (code - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		if n > uint64(len(dec.buf)-dec.pos) {
+			return nil, errMsgpackShort
+		}
+		return dec.decodeMap(int(n), depth)
+	}
+	return nil, errors.New("msgpack data has an invalid type byte 0x" + strconv.FormatUint(uint64(code), 16))
+}
+
+// msgpackFramer copies the raw data of a single msgpack value from a stream, only reading as far as the headers of the value say it extends.
+type msgpackFramer struct {
+	reader *bufio.Reader
+	buf    bytes.Buffer
+}
+
+func (f *msgpackFramer) copy(n uint64) error {
+	copied, err := io.CopyN(&f.buf, f.reader, int64(n))
+	if err == io.EOF && uint64(copied) < n {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// uint copies a big endian unsigned integer of the supplied size and returns its value.
+func (f *msgpackFramer) uint(size int) (uint64, error) {
+	if err := f.copy(uint64(size)); err != nil {
+		return 0, err
+	}
+	dec := &msgpackDecoder{buf: f.buf.Bytes(), pos: f.buf.Len() - size}
+	return dec.uint(size)
+}
+
+func (f *msgpackFramer) values(n uint64, depth int) error {
+	for i := uint64(0); i < n; i++ {
+		if err := f.value(depth + 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *msgpackFramer) value(depth int) error {
+	if depth > msgpackMaxDepth {
+		return errors.New("msgpack data is nested too deeply")
+	}
+
+	code, err := f.reader.ReadByte()
+	if err != nil {
+		if depth > 0 && err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	f.buf.WriteByte(code)
+
+	switch {
+	case code <= 0x7f, code >= 0xe0:
+		return nil
+	case code&0xf0 == 0x80:
+		return f.values(2*uint64(code&0x0f), depth)
+	case code&0xf0 == 0x90:
+		return f.values(uint64(code&0x0f), depth)
+	case code&0xe0 == 0xa0:
+		return f.copy(uint64(code & 0x1f))
+	}
+
+	switch code {
+	case 0xc0, 0xc2, 0xc3:
+		return nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := f.uint(1 << (code - 0xc4))
+		if err != nil {
+			return err
+		}
+		return f.copy(n)
+	case 0xc7, 0xc8, 0xc9:
+		n, err := f.uint(1 << (code - 0xc7))
+		if err != nil {
+			return err
+		}
+		return f.copy(n + 1)
+	case 0xca:
+		return f.copy(4)
+	case 0xcb:
+		return f.copy(8)
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		return f.copy(1 << (code - 0xcc))
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		return f.copy(1 << (code - 0xd0))
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return f.copy(1 + 1<<(code-0xd4))
+	case 0xd9, 0xda, 0xdb:
+		n, err := f.uint(1 << (code - 0xd9))
+		if err != nil {
+			return err
+		}
+		return f.copy(n)
+	case 0xdc, 0xdd:
+		n, err := f.uint(2 << (code - 0xdc))
+		if err != nil {
+			return err
+		}
+		return f.values(n, depth)
+	case 0xde, 0xdf:
+		n, err := f.uint(2 << (code - 0xde))
+		if err != nil {
+			return err
+		}
+		return f.values(2*n, depth)
+	}
+	return errors.New("msgpack data has an invalid type byte 0x" + strconv.FormatUint(uint64(code), 16))
+}
+
+// ReadFrame will read the raw data of the next msgpack value from the supplied stream, msgpack values can contain any byte so they are never split on new lines.
+func (msgpack *Msgpack) ReadFrame(reader *bufio.Reader) ([]byte, error) {
+	f := &msgpackFramer{reader: reader}
+	if err := f.value(0); err != nil {
+		return nil, err
+	}
+	return f.buf.Bytes(), nil
+}
+
+// Decode will parse every msgpack value in the supplied frame into the data of a separate event, values that are not maps are wrapped in the 'message' field.
+func (msgpack *Msgpack) Decode(frame []byte) ([]map[string]interface{}, error) {
+	dec := &msgpackDecoder{buf: frame}
+
+	ret := make([]map[string]interface{}, 0, 1)
+	for dec.pos < len(dec.buf) {
+		value, err := dec.decode(0)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, wrap(value))
+	}
+	return ret, nil
+}
+
+// Encode will return the supplied event as a msgpack map with the same structure as the json representation of the event, msgpack values are self delimiting so no delimiter is added.
+func (msgpack *Msgpack) Encode(event *common.Event) ([]byte, error) {
+	enc := &msgpackEncoder{}
+	if err := enc.encode(envelope(event)); err != nil {
+		return nil, err
+	}
+	return enc.buf.Bytes(), nil
+}
+
+// Name returns 'msgpack'.
+func (msgpack *Msgpack) Name() string {
+	return MsgpackCodec
+}
+
+// FailureTag returns the MsgpackFailureTag.
+func (msgpack *Msgpack) FailureTag() string {
+	return MsgpackFailureTag
+}
+
+func newMsgpack(config *common.Config, pluginConfig *common.PluginConfig) (Codec, error) {
+	return &Msgpack{config: config, pluginConfig: pluginConfig}, nil
+}
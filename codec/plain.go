@@ -0,0 +1,51 @@
+// Copyright (c) 2017 Christian Saide <Supernomad>
+// Licensed under the MPL-2.0, for details see https://github.com/Supernomad/protond/blob/master/LICENSE
+
+package codec
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/Supernomad/protond/common"
+)
+
+// Plain is a struct representing the plain text codec.
+type Plain struct {
+	config       *common.Config
+	pluginConfig *common.PluginConfig
+}
+
+// Decode will return the supplied frame, without its trailing new line, as the 'message' field of a single event.
+func (plain *Plain) Decode(frame []byte) ([]map[string]interface{}, error) {
+	return []map[string]interface{}{
+		{"message": strings.TrimRight(string(frame), "\r\n")},
+	}, nil
+}
+
+// Encode will return the 'message' field of the supplied event followed by a new line, events without a 'message' field are written as json.
+func (plain *Plain) Encode(event *common.Event) ([]byte, error) {
+	if message, ok := event.Data["message"]; ok {
+		return []byte(formatValue(message) + "\n"), nil
+	}
+
+	buf, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	return append(buf, '\n'), nil
+}
+
+// Name returns 'plain'.
+func (plain *Plain) Name() string {
+	return PlainCodec
+}
+
+// FailureTag returns an empty string as the plain codec never fails to decode.
+func (plain *Plain) FailureTag() string {
+	return ""
+}
+
+func newPlain(config *common.Config, pluginConfig *common.PluginConfig) (Codec, error) {
+	return &Plain{config: config, pluginConfig: pluginConfig}, nil
+}
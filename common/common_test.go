@@ -28,7 +28,7 @@ func TestNewConfig(t *testing.T) {
 	os.Setenv("PROTOND_CONF_FILE", confFile)
 	os.Setenv("PROTOND_PID_FILE", "../protond.pid")
 
-	os.Args = append(os.Args, "-w", "100", "-f", "../dist/test/filters.d")
+	os.Args = append(os.Args, "-w", "100", "-f", "../dist/test/filters.d", "-a", "../dist/test/alerts.d")
 	config, err := NewConfig(NewLogger(NoopLogger))
 
 	if err != nil {
@@ -46,6 +46,15 @@ func TestNewConfig(t *testing.T) {
 	if config.PidFile != "../protond.pid" {
 		t.Fatal("NewConfig didn't pick up the environment variable replacement for PidFile")
 	}
+	if len(config.Filters) != 1 || config.Filters[0].Name != "testing.js" {
+		t.Fatal("NewConfig didn't pick up the filters from the FilterDirectory")
+	}
+	if len(config.Pipelines) != 1 || len(config.Pipelines["syslog"]) != 1 || config.Pipelines["syslog"][0].Name != "parse.js" {
+		t.Fatal("NewConfig didn't pick up the pipelines from the FilterDirectory")
+	}
+	if len(config.Alerts) != 1 || config.Alerts[0].Name != "Test Noop Alert" {
+		t.Fatal("NewConfig didn't pick up the alert configurations from the AlertDirectory")
+	}
 
 	config.parseSpecial([]string{"-h", "-v"}, false)
 }
@@ -164,3 +173,109 @@ func TestParsePluginConfigsIncorrectPath(t *testing.T) {
 		t.Fatal("ParsePluginConfigs failed to skip a non-existent path.")
 	}
 }
+
+func TestEventDerive(t *testing.T) {
+	event := &Event{
+		Timestamp: time.Now(),
+		Input:     "testing",
+		Data:      map[string]interface{}{"message": "original"},
+	}
+
+	derived := event.Derive(map[string]interface{}{"message": "derived"})
+	if derived == event || derived.Timestamp != event.Timestamp || derived.Input != event.Input {
+		t.Fatal("Event.Derive did not create a new event carrying the original timestamp and input.")
+	}
+	if event.Data["message"] != "original" || derived.Data["message"] != "derived" {
+		t.Fatal("Event.Derive modified the original event data.")
+	}
+}
+
+func TestEventFieldAndTags(t *testing.T) {
+	event := &Event{
+		Timestamp: time.Now(),
+		Data: map[string]interface{}{
+			"message": "woot",
+			"sub":     map[string]interface{}{"obj": map[string]interface{}{"value": 42}},
+		},
+	}
+
+	if value, ok := event.Field("sub.obj.value"); !ok || value != 42 {
+		t.Fatal("Event.Field did not return a nested field.")
+	}
+	if _, ok := event.Field("message.value"); ok {
+		t.Fatal("Event.Field returned a nested field of a non object.")
+	}
+	if _, ok := event.Field("missing"); ok {
+		t.Fatal("Event.Field returned a field that doesn't exist.")
+	}
+
+	if !event.SetField("sub.new.value", "set") || event.SetField("message.value", "set") {
+		t.Fatal("Event.SetField did not create the nested field, or overwrote a non object.")
+	}
+	if value, ok := event.Field("sub.new.value"); !ok || value != "set" {
+		t.Fatal("Event.SetField did not set the nested field.")
+	}
+	if !event.DeleteField("sub.obj.value") || event.DeleteField("sub.obj.value") {
+		t.Fatal("Event.DeleteField did not remove the nested field exactly once.")
+	}
+
+	event.AddTag("audit")
+	event.AddTag("audit")
+	if len(event.Tags) != 1 || !event.HasTag("audit") || event.HasTag("metrics") {
+		t.Fatal("Event.AddTag or Event.HasTag didn't handle tags properly.")
+	}
+}
+
+func TestParseFilterConfigsOrdering(t *testing.T) {
+	configs, err := ParseFilterConfigs("../dist/test/ordered.d", NewLogger(NoopLogger))
+	if err != nil {
+		t.Fatalf("ParseFilterConfigs returned an error: %s", err.Error())
+	}
+
+	if len(configs) != 3 || configs[0].Name != "2-first.js" || configs[1].Name != "10-second.js" || configs[2].Name != "last.js" {
+		t.Fatal("ParseFilterConfigs didn't order the filters by their file name prefix.")
+	}
+}
+
+func TestParseFilterConfigsManifest(t *testing.T) {
+	configs, err := ParseFilterConfigs("../dist/test/manifest.d", NewLogger(NoopLogger))
+	if err != nil {
+		t.Fatalf("ParseFilterConfigs returned an error: %s", err.Error())
+	}
+
+	if len(configs) != 2 {
+		t.Fatal("ParseFilterConfigs didn't skip the disabled filter in the manifest.")
+	}
+	if configs[0].Name != "Noop" || configs[0].Type != "noop" || configs[1].Name != "Threshold" || configs[1].Type != "js" {
+		t.Fatal("ParseFilterConfigs didn't order the filters by their manifest priority.")
+	}
+	if configs[0].If != "exists(message)" {
+		t.Fatal("ParseFilterConfigs didn't load the if condition of the manifest filter.")
+	}
+	if configs[1].Code == "" || configs[1].Config["threshold"] != "5" {
+		t.Fatal("ParseFilterConfigs didn't load the code and config of the manifest filter.")
+	}
+}
+
+func TestParseFilterConfigsFiles(t *testing.T) {
+	configs, err := ParseFilterConfigs("../dist/test/native.d", NewLogger(NoopLogger))
+	if err != nil {
+		t.Fatalf("ParseFilterConfigs returned an error: %s", err.Error())
+	}
+
+	if len(configs) != 3 {
+		t.Fatal("ParseFilterConfigs didn't skip the filter configuration file without a type.")
+	}
+	if configs[0].Name != "10-syslog.yml" || configs[0].Type != "grok" || configs[0].Config["match"] != "%{SYSLOGLINE}" {
+		t.Fatal("ParseFilterConfigs didn't load the yaml filter configuration file.")
+	}
+	if configs[1].Name != "Pairs" || configs[1].Type != "kv" || configs[1].Config["target"] != "pairs" {
+		t.Fatal("ParseFilterConfigs didn't load the json filter configuration file.")
+	}
+	if len(configs[0].ConfigKeys) != 2 || configs[0].ConfigKeys[0] != "source" || configs[0].ConfigKeys[1] != "match" || len(configs[1].ConfigKeys) != 2 || configs[1].ConfigKeys[0] != "target" || configs[1].ConfigKeys[1] != "source" {
+		t.Fatal("ParseFilterConfigs didn't keep the order of the filter configuration keys.")
+	}
+	if configs[2].Name != "5-date.yml" || configs[2].Priority != 30 {
+		t.Fatal("ParseFilterConfigs didn't prefer the explicit priority of the filter configuration file over its file name prefix.")
+	}
+}
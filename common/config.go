@@ -35,20 +35,27 @@ The user supplied configuration is processed via a structured hierarchy:
 The only exceptions to the above are the two special cli argments '-h'|'--help' or '-v'|'--version' which will output usage information or version information respectively and then exit the application.
 */
 type Config struct {
-	ConfFile        string            `skip:"false"  type:"string"    short:"c"    long:"conf-file"         default:""                              description:"The configuration file to use to configure protond."`
-	Backlog         int               `skip:"false"  type:"int"       short:"b"    long:"backlog"           default:"1024"                          description:"The number of in flight events allowed per worker."`
-	NumWorkers      int               `skip:"false"  type:"int"       short:"w"    long:"workers"           default:"0"                             description:"The number of protond workers to use, set to 0 for a worker per available cpu core."`
-	FilterTimeout   time.Duration     `skip:"false"  type:"duration"  short:"t"    long:"filter-timeout"    default:"10s"                           description:"The maximum amount of time any filter can run before timing out and failing."`
-	InputDirectory  string            `skip:"false"  type:"string"    short:"i"    long:"input-directory"   default:"/etc/protond/inputs.d"         description:"The directory containing arbitrary input filters for protond to use for ingesting events."`
-	OutputDirectory string            `skip:"false"  type:"string"    short:"o"    long:"output-directory"  default:"/etc/protond/outputs.d"        description:"The directory containing arbitrary input filters for protond to use for ingesting events."`
-	FilterDirectory string            `skip:"false"  type:"string"    short:"f"    long:"filter-directory"  default:"/etc/protond/filters.d"        description:"The directory containing arbitrary javascript filters for protond to use for event filtering."`
-	DataDir         string            `skip:"false"  type:"string"    short:"d"    long:"data-dir"          default:"/var/lib/protond"              description:"The directory to store local protond state to."`
-	PidFile         string            `skip:"false"  type:"string"    short:"p"    long:"pid-file"          default:"/var/run/protond/protond.pid"  description:"The pid file to use for tracking rolling restarts."`
-	Log             *Logger           `skip:"true"` // The internal logger to use
-	Inputs          []*PluginConfig   `skip:"true"` // The raw input configurations to use for event ingestion
-	Outputs         []*PluginConfig   `skip:"true"` // The raw input configurations to use for event propagation
-	Filters         []*FilterConfig   `skip:"true"` // The raw javascript filters to use during event filtering
-	fileData        map[string]string `skip:"true"` // An internal map of data representing a passed in configuration file
+	ConfFile        string                     `skip:"false"  type:"string"    short:"c"    long:"conf-file"         default:""                              description:"The configuration file to use to configure protond."`
+	Backlog         int                        `skip:"false"  type:"int"       short:"b"    long:"backlog"           default:"1024"                          description:"The number of in flight events allowed per worker."`
+	NumWorkers      int                        `skip:"false"  type:"int"       short:"w"    long:"workers"           default:"0"                             description:"The number of protond workers to use, set to 0 for a worker per available cpu core."`
+	FilterTimeout   time.Duration              `skip:"false"  type:"duration"  short:"t"    long:"filter-timeout"    default:"10s"                           description:"The maximum amount of time any filter can run before timing out and failing."`
+	InputDirectory  string                     `skip:"false"  type:"string"    short:"i"    long:"input-directory"   default:"/etc/protond/inputs.d"         description:"The directory containing arbitrary input filters for protond to use for ingesting events."`
+	OutputDirectory string                     `skip:"false"  type:"string"    short:"o"    long:"output-directory"  default:"/etc/protond/outputs.d"        description:"The directory containing arbitrary input filters for protond to use for ingesting events."`
+	FilterDirectory string                     `skip:"false"  type:"string"    short:"f"    long:"filter-directory"  default:"/etc/protond/filters.d"        description:"The directory containing arbitrary javascript filters for protond to use for event filtering."`
+	AlertDirectory  string                     `skip:"false"  type:"string"    short:"a"    long:"alert-directory"   default:"/etc/protond/alerts.d"         description:"The directory containing arbitrary alert configurations for protond filters to use for emitting alerts."`
+	DataDir         string                     `skip:"false"  type:"string"    short:"d"    long:"data-dir"          default:"/var/lib/protond"              description:"The directory to store local protond state to."`
+	CacheType       string                     `skip:"false"  type:"string"    short:"C"    long:"cache-type"        default:"memory"                        description:"The type of internal cache filters use to store events, either 'memory' or 'disk' to persist the cache under the data directory."`
+	CacheTTL        time.Duration              `skip:"false"  type:"duration"  short:"T"    long:"cache-ttl"         default:"0s"                            description:"The amount of time events are kept in the internal cache, set to 0s to keep events until they are evicted."`
+	CacheMaxLength  int                        `skip:"false"  type:"int"       short:"L"    long:"cache-max-length"  default:"0"                             description:"The maximum number of events kept per internal cache key, discarding the oldest events first, set to 0 for no limit."`
+	CacheMaxKeys    int                        `skip:"false"  type:"int"       short:"K"    long:"cache-max-keys"    default:"0"                             description:"The maximum number of keys kept in the internal cache, evicting the least recently used keys first, set to 0 for no limit."`
+	PidFile         string                     `skip:"false"  type:"string"    short:"p"    long:"pid-file"          default:"/var/run/protond/protond.pid"  description:"The pid file to use for tracking rolling restarts."`
+	Log             *Logger                    `skip:"true"` // The internal logger to use
+	Inputs          []*PluginConfig            `skip:"true"` // The raw input configurations to use for event ingestion
+	Outputs         []*PluginConfig            `skip:"true"` // The raw input configurations to use for event propagation
+	Filters         []*FilterConfig            `skip:"true"` // The raw javascript filters to use during event filtering
+	Pipelines       map[string][]*FilterConfig `skip:"true"` // The raw named filter chains, which inputs can reference instead of the default filter chain
+	Alerts          []*PluginConfig            `skip:"true"` // The raw alert configurations to use for emitting alerts from filters
+	fileData        map[string]string          `skip:"true"` // An internal map of data representing a passed in configuration file
 }
 
 func (config *Config) cliArg(short, long string, isFlag bool) (string, bool) {
@@ -219,7 +226,7 @@ func (config *Config) computeArgs() error {
 		config.NumWorkers = numCPU
 	}
 
-	os.MkdirAll(config.DataDir, os.ModeDir)
+	os.MkdirAll(config.DataDir, 0755)
 	os.MkdirAll(path.Dir(config.PidFile), os.ModeDir)
 
 	pid := os.Getpid()
@@ -230,31 +237,29 @@ func (config *Config) computeArgs() error {
 	}
 
 	if PathExists(config.FilterDirectory) {
+		filterConfigs, err := ParseFilterConfigs(config.FilterDirectory, config.Log)
+		if err != nil {
+			return err
+		}
+		config.Filters = filterConfigs
+
 		filterFiles, err := ioutil.ReadDir(config.FilterDirectory)
 		if err != nil {
 			return err
 		}
 
-		config.Filters = make([]*FilterConfig, 0)
+		config.Pipelines = make(map[string][]*FilterConfig)
 		for i := 0; i < len(filterFiles); i++ {
+			if !filterFiles[i].IsDir() {
+				continue
+			}
+
 			name := filterFiles[i].Name()
-			ext := path.Ext(name)
-			switch ext {
-			case ".js":
-				fileData, err := ioutil.ReadFile(path.Join(config.FilterDirectory, name))
-				if err != nil {
-					return err
-				}
-
-				filterConfig := &FilterConfig{
-					Type: ext[1:],
-					Name: name,
-					Code: string(fileData),
-				}
-				config.Filters = append(config.Filters, filterConfig)
-			default:
-				config.Log.Warn.Printf("Filter file '%s' is not one of the compatible filter types: 'js'.", name)
+			pipelineConfigs, err := ParseFilterConfigs(path.Join(config.FilterDirectory, name), config.Log)
+			if err != nil {
+				return err
 			}
+			config.Pipelines[name] = pipelineConfigs
 		}
 	} else {
 		config.Log.Warn.Println("The specified FilterDirectory path does not exist, using Noop filter.")
@@ -272,6 +277,12 @@ func (config *Config) computeArgs() error {
 	}
 	config.Outputs = outputConfigs
 
+	alertConfigs, err := ParsePluginConfigs(config.AlertDirectory, config.Log)
+	if err != nil {
+		return err
+	}
+	config.Alerts = alertConfigs
+
 	return nil
 }
 
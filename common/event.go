@@ -5,6 +5,7 @@ package common
 
 import (
 	"encoding/json"
+	"strings"
 	"time"
 )
 
@@ -12,6 +13,10 @@ import (
 type Event struct {
 	Timestamp time.Time              `json:"timestamp"`
 	Input     string                 `json:"input"`
+	Tags      []string               `json:"tags,omitempty"`
+	Routes    []string               `json:"-"`
+	Received  time.Time              `json:"-"`
+	Worker    int                    `json:"-"`
 	Data      map[string]interface{} `json:"data"`
 }
 
@@ -31,6 +36,101 @@ func (e *Event) String(pretty bool) string {
 	return string(e.Bytes(pretty))
 }
 
+// Derive will return a new independent event carrying the metadata of the original event along with the supplied data.
+func (e *Event) Derive(data map[string]interface{}) *Event {
+	return &Event{
+		Timestamp: e.Timestamp,
+		Input:     e.Input,
+		Tags:      append([]string(nil), e.Tags...),
+		Routes:    append([]string(nil), e.Routes...),
+		Received:  e.Received,
+		Worker:    e.Worker,
+		Data:      data,
+	}
+}
+
+// HasTag determines whether or not the event has been tagged with the supplied tag.
+func (e *Event) HasTag(tag string) bool {
+	for i := 0; i < len(e.Tags); i++ {
+		if e.Tags[i] == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// AddTag will tag the event with the supplied tag, if the event is not already tagged with it.
+func (e *Event) AddTag(tag string) {
+	if !e.HasTag(tag) {
+		e.Tags = append(e.Tags, tag)
+	}
+}
+
+// SetField will set the value of the supplied field in the event data, creating any missing intermediate objects of a nested '.' separated path, false is returned if an intermediate field exists but is not an object.
+func (e *Event) SetField(path string, value interface{}) bool {
+	if e.Data == nil {
+		e.Data = make(map[string]interface{})
+	}
+
+	keys := strings.Split(path, ".")
+	current := e.Data
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := current[key]
+		if !ok {
+			obj := make(map[string]interface{})
+			current[key] = obj
+			current = obj
+			continue
+		}
+
+		obj, ok := next.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		current = obj
+	}
+
+	current[keys[len(keys)-1]] = value
+	return true
+}
+
+// DeleteField will remove the supplied field from the event data, supporting nested '.' separated paths, false is returned if the field does not exist.
+func (e *Event) DeleteField(path string) bool {
+	keys := strings.Split(path, ".")
+	current := e.Data
+	for _, key := range keys[:len(keys)-1] {
+		obj, ok := current[key].(map[string]interface{})
+		if !ok {
+			return false
+		}
+		current = obj
+	}
+
+	key := keys[len(keys)-1]
+	if _, ok := current[key]; !ok {
+		return false
+	}
+	delete(current, key)
+	return true
+}
+
+// Field will return the value of the supplied field from the event data, nested fields are addressed with a '.' separated path for example 'a.b.c', the returned bool is false if the field does not exist.
+func (e *Event) Field(path string) (interface{}, bool) {
+	var current interface{} = e.Data
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		current, ok = obj[key]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
 // ParseEventData will convert the supplied string to an Event struct pointer.
 func ParseEventData(str string) (map[string]interface{}, error) {
 	var eventData map[string]interface{}
@@ -3,9 +3,283 @@
 
 package common
 
-// FilterConfig is a struct representing a javascript filters name and underlying code.
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+var manifestFiles = []string{"manifest.yml", "manifest.yaml", "manifest.json"}
+
+// FilterConfig is a struct representing a filters name, underlying code, and user defined parameters, along with the conditional expression an event must satisfy for the filter to run on it.
+// ConfigKeys lists the keys of the user defined parameters in the order they were written in the filter file, and is empty when the order isn't known.
 type FilterConfig struct {
-	Type string
-	Name string
-	Code string
+	Type       string
+	Name       string
+	Code       string
+	If         string
+	Priority   int
+	Config     map[string]string
+	ConfigKeys []string
+}
+
+// filterParams is the user defined parameters of a filter along with the order their keys were written in.
+type filterParams struct {
+	values map[string]string
+	keys   []string
+}
+
+// UnmarshalYAML decodes a yaml mapping of parameters, keeping the order of its keys.
+func (params *filterParams) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&params.values); err != nil {
+		return err
+	}
+
+	var ordered yaml.MapSlice
+	if err := unmarshal(&ordered); err != nil {
+		return err
+	}
+
+	params.keys = make([]string, 0, len(ordered))
+	for _, item := range ordered {
+		if key, ok := item.Key.(string); ok {
+			params.keys = append(params.keys, key)
+		}
+	}
+	return nil
+}
+
+// UnmarshalJSON decodes a json object of parameters, keeping the order of its keys.
+func (params *filterParams) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &params.values); err != nil || params.values == nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if _, err := decoder.Token(); err != nil {
+		return err
+	}
+
+	params.keys = make([]string, 0, len(params.values))
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return err
+		}
+		params.keys = append(params.keys, token.(string))
+	}
+	return nil
+}
+
+// filterManifest is a struct representing a filter directory manifest, which explicitly defines the filters to load and their order.
+type filterManifest struct {
+	Filters []*filterManifestEntry `json:"filters" yaml:"filters"`
+}
+
+type filterManifestEntry struct {
+	Name     string       `json:"name" yaml:"name"`
+	Type     string       `json:"type" yaml:"type"`
+	File     string       `json:"file" yaml:"file"`
+	Priority *int         `json:"priority" yaml:"priority"`
+	If       string       `json:"if" yaml:"if"`
+	Enabled  *bool        `json:"enabled" yaml:"enabled"`
+	Config   filterParams `json:"config" yaml:"config"`
+}
+
+// filenamePriority parses the numeric prefix of a filter file name, for example '10-parse.js' has a priority of 10.
+func filenamePriority(name string) (int, bool) {
+	idx := strings.IndexAny(name, "-_")
+	if idx <= 0 {
+		return 0, false
+	}
+
+	priority, err := strconv.Atoi(name[:idx])
+	if err != nil {
+		return 0, false
+	}
+	return priority, true
+}
+
+func unmarshalFilterFile(dir, file string, v interface{}) error {
+	fileData, err := ioutil.ReadFile(path.Join(dir, file))
+	if err != nil {
+		return err
+	}
+
+	switch path.Ext(file) {
+	case ".json":
+		return json.Unmarshal(fileData, v)
+	default:
+		return yaml.Unmarshal(fileData, v)
+	}
+}
+
+func (entry *filterManifestEntry) filterConfig(dir string) (*FilterConfig, error) {
+	filterConfig := &FilterConfig{
+		Type:       entry.Type,
+		Name:       entry.Name,
+		If:         entry.If,
+		Config:     entry.Config.values,
+		ConfigKeys: entry.Config.keys,
+	}
+
+	if entry.Priority != nil {
+		filterConfig.Priority = *entry.Priority
+	}
+
+	if entry.File != "" {
+		code, err := ioutil.ReadFile(path.Join(dir, entry.File))
+		if err != nil {
+			return nil, err
+		}
+		filterConfig.Code = string(code)
+
+		if filterConfig.Type == "" {
+			filterConfig.Type = strings.TrimPrefix(path.Ext(entry.File), ".")
+		}
+		if filterConfig.Name == "" {
+			filterConfig.Name = entry.File
+		}
+	}
+
+	if filterConfig.Name == "" {
+		filterConfig.Name = filterConfig.Type
+	}
+	if filterConfig.Config == nil {
+		filterConfig.Config = make(map[string]string)
+	}
+
+	return filterConfig, nil
+}
+
+func parseFilterManifest(dir, file string, log *Logger) ([]*FilterConfig, error) {
+	var manifest filterManifest
+	if err := unmarshalFilterFile(dir, file, &manifest); err != nil {
+		return nil, err
+	}
+
+	configs := make([]*FilterConfig, 0)
+	for i, entry := range manifest.Filters {
+		if entry.Enabled != nil && !*entry.Enabled {
+			log.Debug.Printf("Filter '%s' is disabled in the manifest '%s'.", entry.Name, path.Join(dir, file))
+			continue
+		}
+
+		if entry.File == "" && entry.Type == "" {
+			return nil, errors.New("filter manifest '" + path.Join(dir, file) + "' entry " + strconv.Itoa(i) + " must define either a 'file' or a 'type'")
+		}
+
+		filterConfig, err := entry.filterConfig(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		configs = append(configs, filterConfig)
+	}
+
+	sort.SliceStable(configs, func(i, j int) bool {
+		return configs[i].Priority < configs[j].Priority
+	})
+
+	return configs, nil
+}
+
+// ParseFilterConfigs parses a directory of filter files and returns the resulting array of configs, sub directories are skipped as they represent named pipelines.
+// If the directory contains a 'manifest.yml', 'manifest.yaml', or 'manifest.json' file, only the filters it defines are loaded in order of their priority.
+// Otherwise every filter file is loaded, ordered by the numeric prefix of the file name for example '10-parse.js', with files lacking a prefix loaded last in name order.
+// Filter files are either javascript files, or 'yml', 'yaml', or 'json' files defining a single filter in the same format as a manifest entry, where an explicit 'priority' overrides the file name prefix.
+func ParseFilterConfigs(dir string, log *Logger) ([]*FilterConfig, error) {
+	for _, manifest := range manifestFiles {
+		if PathExists(path.Join(dir, manifest)) {
+			return parseFilterManifest(dir, manifest, log)
+		}
+	}
+
+	filterFiles, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	configs := make([]*FilterConfig, 0)
+	prefixed := make(map[*FilterConfig]bool)
+	for i := 0; i < len(filterFiles); i++ {
+		if filterFiles[i].IsDir() {
+			continue
+		}
+
+		name := filterFiles[i].Name()
+		ext := path.Ext(name)
+		switch ext {
+		case ".js":
+			fileData, err := ioutil.ReadFile(path.Join(dir, name))
+			if err != nil {
+				return nil, err
+			}
+
+			filterConfig := &FilterConfig{
+				Type:   ext[1:],
+				Name:   name,
+				Code:   string(fileData),
+				Config: make(map[string]string),
+			}
+			filterConfig.Priority, prefixed[filterConfig] = filenamePriority(name)
+
+			configs = append(configs, filterConfig)
+		case ".yml", ".yaml", ".json":
+			var entry filterManifestEntry
+			if err := unmarshalFilterFile(dir, name, &entry); err != nil {
+				return nil, err
+			}
+
+			if entry.Type == "" && entry.File == "" {
+				log.Warn.Printf("Filter configuration file '%s' does not define a filter 'type' or 'file'.", name)
+				continue
+			}
+
+			if entry.Enabled != nil && !*entry.Enabled {
+				log.Debug.Printf("Filter configuration file '%s' is disabled.", name)
+				continue
+			}
+
+			filterConfig, err := entry.filterConfig(dir)
+			if err != nil {
+				return nil, err
+			}
+			if filterConfig.Name == filterConfig.Type {
+				filterConfig.Name = name
+			}
+
+			// An explicit priority in the file takes precedence over the numeric prefix of the file name.
+			if entry.Priority != nil {
+				prefixed[filterConfig] = true
+			} else {
+				filterConfig.Priority, prefixed[filterConfig] = filenamePriority(name)
+			}
+
+			configs = append(configs, filterConfig)
+		default:
+			log.Warn.Printf("Filter file '%s' is not one of the compatible filter types: 'js', 'yml', 'yaml', or 'json'.", name)
+		}
+	}
+
+	sort.SliceStable(configs, func(i, j int) bool {
+		if prefixed[configs[i]] != prefixed[configs[j]] {
+			return prefixed[configs[i]]
+		}
+		return configs[i].Priority < configs[j].Priority
+	})
+
+	return configs, nil
 }
@@ -54,7 +54,7 @@ func ParsePluginConfigs(dir string, log *Logger) ([]*PluginConfig, error) {
 					return nil, err
 				}
 			default:
-				log.Warn.Printf("Input or Output configuration file '%s' is not one of the compatible configuration file types: 'json', 'yml', or 'yaml'.", name)
+				log.Warn.Printf("Plugin configuration file '%s' is not one of the compatible configuration file types: 'json', 'yml', or 'yaml'.", name)
 				continue
 			}
 
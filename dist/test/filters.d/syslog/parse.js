@@ -0,0 +1 @@
+event.pipeline = "syslog"
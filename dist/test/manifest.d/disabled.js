@@ -0,0 +1 @@
+event.disabled = true
@@ -0,0 +1 @@
+event.threshold = config.threshold
@@ -0,0 +1 @@
+event.order = (event.order || []).concat(["2"])
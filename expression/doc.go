@@ -0,0 +1,22 @@
+// Copyright (c) 2017 Christian Saide <Supernomad>
+// Licensed under the MPL-2.0, for details see https://github.com/Supernomad/protond/blob/master/LICENSE
+
+/*
+Package expression implements the small conditional expression language used to guard filters and route events to outputs.
+
+An expression is evaluated against a single event and supports the following:
+  - Operands
+    - Event fields addressed by name or by a nested '.' separated path for example 'a.b.c', missing fields evaluate to 'null'.
+    - The '@input' and '@tags' variables exposing the name of the input the event was read from and the list of tags on the event.
+    - Single or double quoted strings, numbers, 'true', 'false', 'null', and lists of operands for example '["a", "b"]'.
+  - Comparisons
+    - '==', '!=', '<', '<=', '>', and '>=', which compare numerically when both sides are numbers and lexically when both sides are strings.
+    - '=~' and '!~', which match the left side against the regular expression string on the right side.
+    - 'in' and 'not in', which check for membership in a list, a substring of a string, or a key of an object.
+  - Boolean logic
+    - '&&', '||', '!', and parentheses for grouping, an operand on its own is true when it is not 'null', 'false', '0', or empty.
+    - 'exists(field)', which is true when the field is present in the event even if its value is 'null'.
+
+For example: status >= 500 && request =~ "^/api/" && !("debug" in @tags)
+*/
+package expression
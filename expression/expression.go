@@ -0,0 +1,489 @@
+// Copyright (c) 2017 Christian Saide <Supernomad>
+// Licensed under the MPL-2.0, for details see https://github.com/Supernomad/protond/blob/master/LICENSE
+
+package expression
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Supernomad/protond/common"
+)
+
+// Expression is a compiled conditional expression that can be evaluated against events.
+type Expression struct {
+	source string
+	root   node
+}
+
+type node interface {
+	eval(event *common.Event) interface{}
+}
+
+type literal struct {
+	value interface{}
+}
+
+type field struct {
+	path string
+}
+
+type variable struct {
+	name string
+}
+
+type list struct {
+	items []node
+}
+
+type exists struct {
+	path string
+}
+
+type not struct {
+	operand node
+}
+
+type logical struct {
+	and         bool
+	left, right node
+}
+
+type comparison struct {
+	operator    string
+	left, right node
+	regex       *regexp.Regexp
+}
+
+func (l *literal) eval(event *common.Event) interface{} {
+	return l.value
+}
+
+func (f *field) eval(event *common.Event) interface{} {
+	value, _ := event.Field(f.path)
+	return value
+}
+
+func (v *variable) eval(event *common.Event) interface{} {
+	switch v.name {
+	case "@input":
+		return event.Input
+	case "@tags":
+		tags := make([]interface{}, len(event.Tags))
+		for i := range event.Tags {
+			tags[i] = event.Tags[i]
+		}
+		return tags
+	}
+	return nil
+}
+
+func (l *list) eval(event *common.Event) interface{} {
+	values := make([]interface{}, len(l.items))
+	for i := range l.items {
+		values[i] = l.items[i].eval(event)
+	}
+	return values
+}
+
+func (e *exists) eval(event *common.Event) interface{} {
+	_, ok := event.Field(e.path)
+	return ok
+}
+
+func (n *not) eval(event *common.Event) interface{} {
+	return !truthy(n.operand.eval(event))
+}
+
+func (l *logical) eval(event *common.Event) interface{} {
+	if l.and {
+		return truthy(l.left.eval(event)) && truthy(l.right.eval(event))
+	}
+	return truthy(l.left.eval(event)) || truthy(l.right.eval(event))
+}
+
+func (c *comparison) eval(event *common.Event) interface{} {
+	left := c.left.eval(event)
+
+	switch c.operator {
+	case "=~", "!~":
+		str, ok := left.(string)
+		if !ok {
+			if left == nil {
+				return c.operator == "!~"
+			}
+			str = fmt.Sprint(left)
+		}
+		return c.regex.MatchString(str) == (c.operator == "=~")
+	case "in":
+		return contains(c.right.eval(event), left)
+	case "not in":
+		return !contains(c.right.eval(event), left)
+	}
+
+	right := c.right.eval(event)
+	switch c.operator {
+	case "==":
+		return equal(left, right)
+	case "!=":
+		return !equal(left, right)
+	}
+
+	cmp, ok := compare(left, right)
+	if !ok {
+		return false
+	}
+
+	switch c.operator {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+func toNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+func truthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case []interface{}:
+		return len(v) > 0
+	case map[string]interface{}:
+		return len(v) > 0
+	}
+
+	if number, ok := toNumber(value); ok {
+		return number != 0
+	}
+	return true
+}
+
+func equal(left, right interface{}) bool {
+	if left == nil || right == nil {
+		return left == nil && right == nil
+	}
+
+	if l, ok := toNumber(left); ok {
+		if r, ok := toNumber(right); ok {
+			return l == r
+		}
+	}
+
+	if l, ok := left.(bool); ok {
+		r, ok := right.(bool)
+		return ok && l == r
+	}
+
+	return fmt.Sprint(left) == fmt.Sprint(right)
+}
+
+func compare(left, right interface{}) (int, bool) {
+	if l, ok := toNumber(left); ok {
+		if r, ok := toNumber(right); ok {
+			switch {
+			case l < r:
+				return -1, true
+			case l > r:
+				return 1, true
+			}
+			return 0, true
+		}
+		return 0, false
+	}
+
+	l, ok := left.(string)
+	if !ok {
+		return 0, false
+	}
+	r, ok := right.(string)
+	if !ok {
+		return 0, false
+	}
+	return strings.Compare(l, r), true
+}
+
+func contains(container, value interface{}) bool {
+	switch c := container.(type) {
+	case []interface{}:
+		for i := range c {
+			if equal(c[i], value) {
+				return true
+			}
+		}
+	case string:
+		if value == nil {
+			return false
+		}
+		return strings.Contains(c, fmt.Sprint(value))
+	case map[string]interface{}:
+		if value == nil {
+			return false
+		}
+		_, ok := c[fmt.Sprint(value)]
+		return ok
+	}
+	return false
+}
+
+type parser struct {
+	tokens []*token
+	pos    int
+}
+
+func (p *parser) peek() *token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() *token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isOperator(value string) bool {
+	t := p.peek()
+	return t.kind == tokenOperator && t.value == value
+}
+
+func (p *parser) expect(value string) error {
+	t := p.next()
+	if t.kind != tokenOperator || t.value != value {
+		return unexpected(t, "'"+value+"'")
+	}
+	return nil
+}
+
+func unexpected(t *token, expected string) error {
+	found := "end of expression"
+	if t.kind != tokenEOF {
+		found = "'" + t.value + "'"
+	}
+	return errors.New("expected " + expected + " but found " + found + " at position " + strconv.Itoa(t.pos))
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isOperator("||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logical{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isOperator("&&") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &logical{and: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.isOperator("!") {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &not{operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	t := p.peek()
+	var operator string
+	switch {
+	case t.kind == tokenOperator:
+		switch t.value {
+		case "==", "!=", "<", "<=", ">", ">=", "=~", "!~":
+			operator = t.value
+		}
+	case t.kind == tokenIdent && t.value == "in":
+		operator = "in"
+	case t.kind == tokenIdent && t.value == "not":
+		if following := p.tokens[p.pos+1]; following.kind == tokenIdent && following.value == "in" {
+			p.next()
+			operator = "not in"
+		}
+	}
+
+	if operator == "" {
+		return left, nil
+	}
+	p.next()
+
+	if operator == "=~" || operator == "!~" {
+		t := p.next()
+		if t.kind != tokenString {
+			return nil, unexpected(t, "a regular expression string")
+		}
+
+		regex, err := regexp.Compile(t.value)
+		if err != nil {
+			return nil, errors.New("invalid regular expression at position " + strconv.Itoa(t.pos) + ": " + err.Error())
+		}
+		return &comparison{operator: operator, left: left, regex: regex}, nil
+	}
+
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	return &comparison{operator: operator, left: left, right: right}, nil
+}
+
+func (p *parser) parseOperand() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return &literal{value: t.value}, nil
+	case tokenNumber:
+		number, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return nil, errors.New("invalid number '" + t.value + "' at position " + strconv.Itoa(t.pos))
+		}
+		return &literal{value: number}, nil
+	case tokenVariable:
+		if t.value != "@input" && t.value != "@tags" {
+			return nil, errors.New("unknown variable '" + t.value + "' at position " + strconv.Itoa(t.pos) + ", expected '@input' or '@tags'")
+		}
+		return &variable{name: t.value}, nil
+	case tokenIdent:
+		switch t.value {
+		case "true":
+			return &literal{value: true}, nil
+		case "false":
+			return &literal{value: false}, nil
+		case "null":
+			return &literal{value: nil}, nil
+		case "exists":
+			if p.isOperator("(") {
+				p.next()
+				path := p.next()
+				if path.kind != tokenIdent {
+					return nil, unexpected(path, "a field name")
+				}
+				if err := p.expect(")"); err != nil {
+					return nil, err
+				}
+				return &exists{path: path.value}, nil
+			}
+		}
+		return &field{path: t.value}, nil
+	case tokenOperator:
+		switch t.value {
+		case "(":
+			inner, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return inner, nil
+		case "[":
+			items := make([]node, 0)
+			for !p.isOperator("]") {
+				item, err := p.parseOperand()
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, item)
+
+				if !p.isOperator(",") {
+					break
+				}
+				p.next()
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			return &list{items: items}, nil
+		}
+	}
+	return nil, unexpected(t, "an operand")
+}
+
+// Match evaluates the expression against the supplied event, returning whether or not the event satisfies it.
+func (expression *Expression) Match(event *common.Event) bool {
+	return truthy(expression.root.eval(event))
+}
+
+// String returns the source of the expression.
+func (expression *Expression) String() string {
+	return expression.source
+}
+
+// Compile parses the supplied source into an expression that can be evaluated against events, any syntax error in the source is returned.
+func Compile(source string) (*Expression, error) {
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, errors.New("expression '" + source + "' is invalid: " + err.Error())
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err == nil && p.peek().kind != tokenEOF {
+		err = unexpected(p.peek(), "end of expression")
+	}
+	if err != nil {
+		return nil, errors.New("expression '" + source + "' is invalid: " + err.Error())
+	}
+
+	return &Expression{source: source, root: root}, nil
+}
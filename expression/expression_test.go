@@ -0,0 +1,101 @@
+// Copyright (c) 2017 Christian Saide <Supernomad>
+// Licensed under the MPL-2.0, for details see https://github.com/Supernomad/protond/blob/master/LICENSE
+
+package expression
+
+import (
+	"testing"
+
+	"github.com/Supernomad/protond/common"
+)
+
+var event = &common.Event{
+	Input: "syslog",
+	Tags:  []string{"audit", "prod"},
+	Data: map[string]interface{}{
+		"message": "Accepted publickey for bob",
+		"status":  float64(503),
+		"bytes":   int64(1024),
+		"level":   "error",
+		"ok":      false,
+		"empty":   nil,
+		"request": map[string]interface{}{"path": "/api/v1/users", "method": "GET"},
+		"users":   []interface{}{"bob", "alice"},
+	},
+}
+
+func TestMatch(t *testing.T) {
+	cases := map[string]bool{
+		`status == 503`:                       true,
+		`status != 503`:                       false,
+		`status >= 500 && status < 600`:       true,
+		`bytes > 1000`:                        true,
+		`bytes <= -1`:                         false,
+		`level == "error"`:                    true,
+		`level == 'warn' || level == 'error'`: true,
+		`level > "debug"`:                     true,
+		`level < 5`:                           false,
+		`request.method == "GET"`:             true,
+		`request.path =~ "^/api/"`:            true,
+		`request.path !~ "^/api/"`:            false,
+		`message =~ "(?i)accepted"`:           true,
+		`missing =~ "woot"`:                   false,
+		`missing !~ "woot"`:                   true,
+		`level in ["warn", "error"]`:          true,
+		`level not in ["warn", "error"]`:      false,
+		`"bob" in users`:                      true,
+		`"bob" in message`:                    true,
+		`"path" in request`:                   true,
+		`"audit" in @tags`:                    true,
+		`!("debug" in @tags)`:                 true,
+		`@input == "syslog"`:                  true,
+		`exists(empty)`:                       true,
+		`exists(missing)`:                     false,
+		`exists(request.path)`:                true,
+		`empty == null`:                       true,
+		`missing == null`:                     true,
+		`ok == false`:                         true,
+		`ok`:                                  false,
+		`!ok`:                                 true,
+		`users`:                               true,
+		`missing`:                             false,
+		`!(status == 503 && level == "warn")`: true,
+		`true && (false || status == 503)`:    true,
+	}
+
+	for source, expected := range cases {
+		expression, err := Compile(source)
+		if err != nil {
+			t.Fatalf("Compile returned an error for '%s': %s", source, err.Error())
+		}
+
+		if expression.Match(event) != expected {
+			t.Fatalf("expression '%s' did not evaluate to '%t'.", source, expected)
+		}
+		if expression.String() != source {
+			t.Fatal("Expression.String did not return the source of the expression.")
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	cases := []string{
+		``,
+		`status ==`,
+		`(status == 503`,
+		`level == "error`,
+		`level =~ 5`,
+		`level =~ "("`,
+		`@missing == 1`,
+		`exists("level")`,
+		`status == 503 level`,
+		`status # 503`,
+		`[1, 2`,
+	}
+
+	for _, source := range cases {
+		if _, err := Compile(source); err == nil {
+			t.Fatalf("Compile did not return an error for '%s'.", source)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2017 Christian Saide <Supernomad>
+// Licensed under the MPL-2.0, for details see https://github.com/Supernomad/protond/blob/master/LICENSE
+
+package expression
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenVariable
+	tokenString
+	tokenNumber
+	tokenOperator
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+// operators is ordered so that the longer operators are matched before their prefixes.
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "=~", "!~", "<", ">", "!", "(", ")", "[", "]", ","}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9') || c == '.' || c == '-'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func lex(source string) ([]*token, error) {
+	tokens := make([]*token, 0)
+
+	for i := 0; i < len(source); {
+		c := source[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"' || c == '\'':
+			var buf bytes.Buffer
+			j := i + 1
+			for ; j < len(source) && source[j] != c; j++ {
+				if source[j] == '\\' && j+1 < len(source) {
+					j++
+				}
+				buf.WriteByte(source[j])
+			}
+			if j >= len(source) {
+				return nil, errors.New("unterminated string starting at position " + strconv.Itoa(i))
+			}
+			tokens = append(tokens, &token{kind: tokenString, value: buf.String(), pos: i})
+			i = j + 1
+		case isDigit(c) || (c == '-' && i+1 < len(source) && isDigit(source[i+1])):
+			j := i + 1
+			for j < len(source) && (isDigit(source[j]) || source[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, &token{kind: tokenNumber, value: source[i:j], pos: i})
+			i = j
+		case c == '@' || isIdentStart(c):
+			j := i + 1
+			for j < len(source) && isIdentChar(source[j]) {
+				j++
+			}
+			kind := tokenIdent
+			if c == '@' {
+				kind = tokenVariable
+			}
+			tokens = append(tokens, &token{kind: kind, value: source[i:j], pos: i})
+			i = j
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(source[i:], op) {
+					tokens = append(tokens, &token{kind: tokenOperator, value: op, pos: i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, errors.New("unexpected character '" + string(c) + "' at position " + strconv.Itoa(i))
+			}
+		}
+	}
+
+	return append(tokens, &token{kind: tokenEOF, pos: len(source)}), nil
+}
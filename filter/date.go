@@ -0,0 +1,177 @@
+// Copyright (c) 2017 Christian Saide <Supernomad>
+// Licensed under the MPL-2.0, for details see https://github.com/Supernomad/protond/blob/master/LICENSE
+
+package filter
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Supernomad/protond/common"
+)
+
+const (
+	// DateFailureTag is the default tag added to events whose field could not be parsed with any of the configured layouts.
+	DateFailureTag = "_dateparsefailure"
+
+	dateUnix     = "UNIX"
+	dateUnixMs   = "UNIX_MS"
+	dateSyslog   = "SYSLOG"
+	dateISO8601  = "ISO8601"
+	dateHTTPDate = "HTTPDATE"
+)
+
+var dateKeywords = map[string][]string{
+	"RFC3339":    {time.RFC3339},
+	"RFC1123":    {time.RFC1123, time.RFC1123Z},
+	dateISO8601:  {"2006-01-02T15:04:05Z07:00", "2006-01-02 15:04:05Z07:00", "2006-01-02T15:04:05", "2006-01-02 15:04:05"},
+	dateSyslog:   {"Jan _2 15:04:05", "Jan 02 15:04:05"},
+	dateHTTPDate: {"02/Jan/2006:15:04:05 -0700"},
+}
+
+// Date is a struct representing the timestamp parsing filter plugin.
+type Date struct {
+	config       *common.Config
+	filterConfig *common.FilterConfig
+	field        string
+	layouts      []string
+	location     *time.Location
+	failureTag   string
+}
+
+func parseEpoch(value interface{}, scale float64) (time.Time, bool) {
+	var epoch float64
+	switch v := value.(type) {
+	case float64:
+		epoch = v
+	case int64:
+		epoch = float64(v)
+	case string:
+		var err error
+		if epoch, err = strconv.ParseFloat(strings.TrimSpace(v), 64); err != nil {
+			return time.Time{}, false
+		}
+	default:
+		return time.Time{}, false
+	}
+
+	secs, frac := math.Modf(epoch / scale)
+	return time.Unix(int64(secs), int64(frac*float64(time.Second))), true
+}
+
+// parse tries each configured layout in turn, returning the first successfully parsed time.
+func (date *Date) parse(value interface{}) (time.Time, bool) {
+	for _, layout := range date.layouts {
+		switch layout {
+		case dateUnix:
+			if parsed, ok := parseEpoch(value, 1); ok {
+				return parsed, true
+			}
+			continue
+		case dateUnixMs:
+			if parsed, ok := parseEpoch(value, 1000); ok {
+				return parsed, true
+			}
+			continue
+		}
+
+		str, ok := value.(string)
+		if !ok {
+			continue
+		}
+		str = strings.TrimSpace(str)
+
+		layouts, keyword := dateKeywords[layout]
+		if !keyword {
+			layouts = []string{layout}
+		}
+
+		for _, l := range layouts {
+			parsed, err := time.ParseInLocation(l, str, date.location)
+			if err != nil {
+				continue
+			}
+
+			// Syslog timestamps don't carry a year, so assume the current one unless that would put the event in the future.
+			if layout == dateSyslog {
+				now := time.Now().In(date.location)
+				parsed = time.Date(now.Year(), parsed.Month(), parsed.Day(), parsed.Hour(), parsed.Minute(), parsed.Second(), parsed.Nanosecond(), date.location)
+				if parsed.After(now.Add(24 * time.Hour)) {
+					parsed = parsed.AddDate(-1, 0, 0)
+				}
+			}
+			return parsed, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (date *Date) fail(event *common.Event) {
+	if date.failureTag != "" {
+		event.AddTag(date.failureTag)
+	}
+}
+
+// Run parses the configured field of the event and sets it as the event timestamp, events whose field is missing or fails to parse are tagged and passed through unchanged.
+func (date *Date) Run(event *common.Event) (*common.Event, error) {
+	value, ok := event.Field(date.field)
+	if !ok {
+		date.fail(event)
+		return event, nil
+	}
+
+	parsed, ok := date.parse(value)
+	if !ok {
+		date.fail(event)
+		return event, nil
+	}
+
+	event.Timestamp = parsed
+	return event, nil
+}
+
+// Name returns the configured name for the date filter.
+func (date *Date) Name() string {
+	return date.filterConfig.Name
+}
+
+func newDate(config *common.Config, filterConfig *common.FilterConfig) (Filter, error) {
+	date := &Date{
+		config:       config,
+		filterConfig: filterConfig,
+		field:        "timestamp",
+		layouts:      []string{"RFC3339"},
+		location:     time.UTC,
+		failureTag:   DateFailureTag,
+	}
+
+	if field := filterConfig.Config["field"]; field != "" {
+		date.field = field
+	}
+
+	if raw := filterConfig.Config["layouts"]; raw != "" {
+		date.layouts = make([]string, 0)
+		for _, layout := range strings.Split(raw, "|") {
+			if layout = strings.TrimSpace(layout); layout != "" {
+				date.layouts = append(date.layouts, layout)
+			}
+		}
+	}
+
+	if raw := filterConfig.Config["timezone"]; raw != "" {
+		location, err := time.LoadLocation(raw)
+		if err != nil {
+			return nil, errors.New("configuration for the date filter, '" + filterConfig.Name + "', has an invalid timezone: " + err.Error())
+		}
+		date.location = location
+	}
+
+	if raw, ok := filterConfig.Config["tag_on_failure"]; ok {
+		date.failureTag = raw
+	}
+
+	return date, nil
+}
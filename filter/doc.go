@@ -8,6 +8,31 @@ Protond currently implements the following filter plugins:
   - Noop
     - A no operation filter which just returns the event unchanged, this is used for pass through protond relays and testing protond.
   - Javascript
-    - This plugin allows for arbitrary javascript scripts that can modify and call certain functions on all events.
+    - This plugin allows for arbitrary javascript scripts that can modify and call certain functions on all events, a script can discard the event by calling 'drop()' or setting 'event' to null, and split it into many events by setting 'event' to an array of objects or calling 'emit(obj)'.
+      Scripts can also call 'tag(name, ...)' to tag the resulting events for output routing conditions, or 'route(output, ...)' to send the resulting events only to the named outputs.
+      The 'meta' object exposes the event 'timestamp' as an ISO 8601 string and as 'epoch_ms', along with the 'input' name, 'worker' id and 'received' time, overwriting either timestamp field sets the timestamp of the resulting events.
+      The 'config' object exposes the parameters defined for the filter in the filter directory manifest.
+      The 'cache' object exposes the internal cache through 'get(key)', 'store(key, obj)', 'getSince(key, since)' where since is a Date, epoch in milliseconds, or ISO 8601 string, 'len(key)', 'keys(prefix)', 'delete(key)', and 'incr(key, n, ttl)' where ttl is a number of milliseconds or a duration string for example '10m'.
+  - JSON
+    - This plugin parses the 'message' field, or the field named by the 'source' parameter, as a json object and merges the resulting fields into the event, or into the field named by the 'target' parameter.
+  - KV
+    - This plugin splits the source field into 'key=value' pairs separated by spaces, quoted values may contain spaces, the 'field_split' and 'value_split' parameters change the separators and the 'prefix' parameter is prepended to every key.
+  - Grok
+    - This plugin matches the source field against the regular expression in the 'match' parameter, which can reference named patterns as '%{PATTERN}', '%{PATTERN:field}', or '%{PATTERN:field:int}' and '%{PATTERN:field:float}' to convert the captured value.
+      A library of patterns is bundled, including 'SYSLOGLINE', 'COMMONAPACHELOG', 'COMBINEDAPACHELOG', 'HTTPD_ERRORLOG', 'NGINXACCESS', and 'NGINXERROR', additional patterns are defined with 'pattern_NAME' parameters.
+      Named groups such as '(?P<field>...)' also capture into a field, except that names starting with '_grok' are reserved.
+  - Mutate
+    - This plugin applies declarative operations to the fields of the event, nested fields are addressed with a '.' separated path for example 'a.b.c'.
+      The 'default_FIELD', 'rename_FIELD', 'copy_FIELD', 'convert_FIELD', 'split_FIELD', and 'join_FIELD' parameters set a default value, rename or copy to a destination field, convert to 'int', 'float', 'bool', or 'string', and split or join on a separator respectively.
+      The 'lowercase', 'uppercase', and 'remove' parameters take a comma separated list of fields, operations are applied in the order their parameters are written in the filter file and events with a field that fails to convert are tagged with '_mutatefailure'.
+  - Date
+    - This plugin parses the 'timestamp' field, or the field named by the 'field' parameter, and sets it as the timestamp of the event, events that fail to parse are tagged with '_dateparsefailure' instead of erroring, the 'tag_on_failure' parameter overrides the tag.
+      The 'layouts' parameter is a '|' separated list of layouts tried in order, either go time layouts or one of the keywords 'RFC3339', 'RFC1123', 'ISO8601', 'HTTPDATE', 'SYSLOG', 'UNIX', or 'UNIX_MS', and defaults to 'RFC3339'.
+      The 'timezone' parameter names the timezone, for example 'America/New_York', used for timestamps that don't define their own and defaults to 'UTC'.
+
+Every filter defined in a filter manifest entry or filter file can define an 'if' conditional expression, see the expression package for the syntax, in which case the filter only runs on the events satisfying it and all other events pass through unchanged.
+
+The JSON, KV, and Grok plugins are configured by a filter manifest entry or a 'yml', 'yaml', or 'json' filter file in the filter directory, for example 'type: grok' along with a 'config' map of parameters.
+They all accept the 'source', 'target', and 'remove_source' parameters, and tag events that fail to parse with '_jsonparsefailure', '_kvparsefailure', or '_grokparsefailure' respectively instead of erroring, the 'tag_on_failure' parameter overrides the tag.
 */
 package filter
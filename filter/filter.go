@@ -17,24 +17,85 @@ const (
 
 	// JavascriptFilter defines a javascript based filter.
 	JavascriptFilter = "js"
+
+	// JSONFilter defines a filter that parses a field of the event as json.
+	JSONFilter = "json"
+
+	// KVFilter defines a filter that parses key value pairs out of a field of the event.
+	KVFilter = "kv"
+
+	// GrokFilter defines a filter that parses a field of the event using named regular expression patterns.
+	GrokFilter = "grok"
+
+	// MutateFilter defines a filter that applies declarative operations to the fields of the event.
+	MutateFilter = "mutate"
+
+	// DateFilter defines a filter that parses a field of the event into the event timestamp.
+	DateFilter = "date"
 )
 
 // Filter is the interface that plugins must adhere to for operation as a filter plugin.
 type Filter interface {
 	// Run should take in the supplied event and preform the filtering, and then return the filtered event and a nil error object, if there is an error during the process the returned event should be the unchanged supplied event and the error object should contain the error.
+	// If the filter decides the event should be discarded it should return a nil event and a nil error object.
 	Run(*common.Event) (*common.Event, error)
 
 	// Name returns the name of the filter plugin.
 	Name() string
 }
 
+// MultiFilter is an optional interface that filter plugins can implement to split a single event into many.
+type MultiFilter interface {
+	Filter
+
+	// RunMulti should behave like Run, except that it returns every event the supplied event was turned into, an empty list of events means the event was dropped.
+	RunMulti(*common.Event) ([]*common.Event, error)
+}
+
+// Apply runs the supplied filter on the supplied event, using RunMulti for filters implementing the MultiFilter interface, and returns the resulting list of events.
+func Apply(filter Filter, event *common.Event) ([]*common.Event, error) {
+	if multi, ok := filter.(MultiFilter); ok {
+		return multi.RunMulti(event)
+	}
+
+	filtered, err := filter.Run(event)
+	if err != nil {
+		return nil, err
+	}
+
+	if filtered == nil {
+		return []*common.Event{}, nil
+	}
+	return []*common.Event{filtered}, nil
+}
+
 // New generates a filter plugin based on the passed in plugin and user defined configuration.
+// Filters with a conditional expression defined are wrapped in a Guard, so that they only run on the events satisfying it.
 func New(filterPlugin string, config *common.Config, filterConfig *common.FilterConfig, internalCache cache.Cache, alerts map[string]alert.Alert) (Filter, error) {
+	var filter Filter
+	var err error
+
 	switch filterPlugin {
 	case NoopFilter:
-		return newNoop(config)
+		filter, err = newNoop(config)
 	case JavascriptFilter:
-		return newJavascript(config, filterConfig, internalCache, alerts)
+		filter, err = newJavascript(config, filterConfig, internalCache, alerts)
+	case JSONFilter:
+		filter, err = newJSON(config, filterConfig)
+	case KVFilter:
+		filter, err = newKV(config, filterConfig)
+	case GrokFilter:
+		filter, err = newGrok(config, filterConfig)
+	case MutateFilter:
+		filter, err = newMutate(config, filterConfig)
+	case DateFilter:
+		filter, err = newDate(config, filterConfig)
+	default:
+		return nil, errors.New("specified filter plugin does not exist")
+	}
+
+	if err != nil {
+		return nil, err
 	}
-	return nil, errors.New("specified filter plugin does not exist")
+	return newGuard(filter, filterConfig)
 }
@@ -4,12 +4,14 @@
 package filter
 
 import (
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/Supernomad/protond/alert"
 	"github.com/Supernomad/protond/cache"
 	"github.com/Supernomad/protond/common"
+	"github.com/robertkrimen/otto"
 )
 
 var (
@@ -402,3 +404,921 @@ func TestJavascriptInterrupt(t *testing.T) {
 		t.Fatal("javascript filter improperly set event value on failure, should be the unchanged supplied event object.")
 	}
 }
+
+type recorder struct {
+	params map[string]interface{}
+}
+
+func (r *recorder) Emit(event *common.Event, params map[string]interface{}) {
+	r.params = params
+}
+
+func (r *recorder) Name() string {
+	return "Recorder"
+}
+
+func TestJavascriptAlertParams(t *testing.T) {
+	filterConfig := &common.FilterConfig{
+		Name: "Test Filter",
+		Code: `
+			alert.emit("Recorder", event, {severity: "critical", runbook: "http://localhost/runbook", routing: ["ops"]})
+		`,
+	}
+	rec := &recorder{}
+	javascript, err := New(JavascriptFilter, config, filterConfig, internalCache, map[string]alert.Alert{"Recorder": rec})
+	if err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+
+	event := &common.Event{
+		Timestamp: time.Now(),
+		Data: map[string]interface{}{
+			"message": 101010101,
+		},
+	}
+
+	_, err = javascript.Run(event)
+	if err != nil {
+		t.Fatalf("Something is very very wrong. %s", err.Error())
+	}
+
+	if rec.params == nil || rec.params["severity"] != "critical" || rec.params["runbook"] != "http://localhost/runbook" || len(rec.params["routing"].([]interface{})) != 1 {
+		t.Fatal("javascript filter did not pass the extra params through to the alert plugin.")
+	}
+}
+
+func TestJavascriptInternalCacheIndependentEvents(t *testing.T) {
+	filterConfig := &common.FilterConfig{
+		Name: "Test Filter",
+		Code: `
+			cache.store("independent", {"message": "first"})
+			cache.store("independent", {"message": "second"})
+			alert.emit("Recorder", {"message": "alert"})
+		`,
+	}
+	rec := &recorder{}
+	javascript, err := New(JavascriptFilter, config, filterConfig, internalCache, map[string]alert.Alert{"Recorder": rec})
+	if err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+
+	event := &common.Event{
+		Timestamp: time.Now(),
+		Input:     "Testing",
+		Data: map[string]interface{}{
+			"message": "woot",
+		},
+	}
+
+	test, err := javascript.Run(event)
+	if err != nil {
+		t.Fatalf("Something is very very wrong. %s", err.Error())
+	}
+
+	if test.Data["message"] != "woot" {
+		t.Fatal("javascript filter allowed 'cache.store' or 'alert.emit' to overwrite the filtered event.")
+	}
+
+	stored := internalCache.Get("independent")
+	if len(stored) != 2 || stored[0] == stored[1] || stored[0] == event || stored[0].Data["message"] != "first" || stored[1].Data["message"] != "second" {
+		t.Fatal("javascript filter stored events that alias each other or the filtered event.")
+	}
+	if stored[0].Input != event.Input || stored[0].Timestamp != event.Timestamp {
+		t.Fatal("javascript filter stored events without the originating timestamp and input.")
+	}
+}
+
+func TestJavascriptCompileError(t *testing.T) {
+	filterConfig := &common.FilterConfig{
+		Name: "Test Filter",
+		Code: `
+			event.message = "testing
+		`,
+	}
+	javascript, err := New(JavascriptFilter, config, filterConfig, internalCache, alerts)
+	if err == nil || javascript != nil {
+		t.Fatal("javascript filter did not fail on a filter that doesn't compile.")
+	}
+}
+
+func TestJavascriptVMReuse(t *testing.T) {
+	filterConfig := &common.FilterConfig{
+		Name: "Test Filter",
+		Code: `
+			event.message = "testing"
+		`,
+	}
+	javascript, err := New(JavascriptFilter, config, filterConfig, internalCache, alerts)
+	if err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+
+	for i := 0; i < 10; i++ {
+		event := &common.Event{
+			Timestamp: time.Now(),
+			Data: map[string]interface{}{
+				"message": i,
+			},
+		}
+
+		test, err := javascript.Run(event)
+		if err != nil {
+			t.Fatalf("Something is very very wrong. %s", err.Error())
+		}
+		if test.Data["message"] != "testing" {
+			t.Fatal("javascript filter failed to filter an event on a reused vm.")
+		}
+	}
+
+	if len(javascript.(*Javascript).vms) != 1 {
+		t.Fatal("javascript filter did not reuse its pooled vm across events.")
+	}
+}
+
+func TestJavascriptVMIsolation(t *testing.T) {
+	filterConfig := &common.FilterConfig{
+		Name: "Test Filter",
+		Code: `
+			if (typeof seen === 'undefined') {
+				seen = 0;
+			}
+			seen++;
+			event.seen = seen;
+			event.patched = Array.prototype.patched === true;
+			Array.prototype.patched = true;
+			event.replaced = typeof drop !== 'function';
+			drop = null;
+		`,
+	}
+	javascript, err := New(JavascriptFilter, config, filterConfig, internalCache, alerts)
+	if err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+
+	for i := 0; i < 3; i++ {
+		event := &common.Event{
+			Timestamp: time.Now(),
+			Data:      map[string]interface{}{},
+		}
+
+		test, err := javascript.Run(event)
+		if err != nil {
+			t.Fatalf("Something is very very wrong. %s", err.Error())
+		}
+		if test.Data["seen"] != float64(1) || test.Data["patched"] != false || test.Data["replaced"] != false {
+			t.Fatal("javascript filter leaked global state from a previous event on a reused vm.")
+		}
+	}
+}
+
+func benchmarkEvent() *common.Event {
+	return &common.Event{
+		Timestamp: time.Now(),
+		Data: map[string]interface{}{
+			"message": "benchmark message",
+			"number":  101010101,
+		},
+	}
+}
+
+func BenchmarkJavascript(b *testing.B) {
+	filterConfig := &common.FilterConfig{
+		Name: "Benchmark Filter",
+		Code: `
+			event.message = event.message.toUpperCase()
+			event.added_field = "woot"
+		`,
+	}
+	javascript, err := New(JavascriptFilter, config, filterConfig, internalCache, alerts)
+	if err != nil {
+		b.Fatal("Something is very very wrong.")
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := javascript.Run(benchmarkEvent()); err != nil {
+			b.Fatalf("Something is very very wrong. %s", err.Error())
+		}
+	}
+}
+
+func BenchmarkJavascriptParallel(b *testing.B) {
+	filterConfig := &common.FilterConfig{
+		Name: "Benchmark Filter",
+		Code: `
+			event.message = event.message.toUpperCase()
+			event.added_field = "woot"
+		`,
+	}
+	javascript, err := New(JavascriptFilter, config, filterConfig, internalCache, alerts)
+	if err != nil {
+		b.Fatal("Something is very very wrong.")
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := javascript.Run(benchmarkEvent()); err != nil {
+				b.Fatalf("Something is very very wrong. %s", err.Error())
+			}
+		}
+	})
+}
+
+// BenchmarkJavascriptFreshVM is the baseline the pooled vms are measured against, it runs the same filter on a new vm for every event.
+func BenchmarkJavascriptFreshVM(b *testing.B) {
+	script, err := otto.New().Compile("Benchmark Filter", `
+		event.message = event.message.toUpperCase()
+		event.added_field = "woot"
+		JSON.stringify(event);
+	`)
+	if err != nil {
+		b.Fatal("Something is very very wrong.")
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		vm := otto.New()
+		vm.Set("event", benchmarkEvent().Data)
+		if _, err := vm.Run(script); err != nil {
+			b.Fatalf("Something is very very wrong. %s", err.Error())
+		}
+	}
+}
+
+func TestJavascriptDrop(t *testing.T) {
+	for _, code := range []string{`drop()`, `event = null`} {
+		filterConfig := &common.FilterConfig{
+			Name: "Test Filter",
+			Code: code,
+		}
+		javascript, err := New(JavascriptFilter, config, filterConfig, internalCache, alerts)
+		if err != nil {
+			t.Fatal("Something is very very wrong.")
+		}
+
+		event := &common.Event{
+			Timestamp: time.Now(),
+			Data: map[string]interface{}{
+				"message": 101010101,
+			},
+		}
+
+		test, err := javascript.Run(event)
+		if err != nil {
+			t.Fatalf("javascript filter errored while dropping an event: %s", err.Error())
+		}
+		if test != nil {
+			t.Fatalf("javascript filter did not drop the event with '%s'.", code)
+		}
+	}
+}
+
+func TestJavascriptSplit(t *testing.T) {
+	filterConfig := &common.FilterConfig{
+		Name: "Test Filter",
+		Code: `
+			var records = event.message.split(",")
+			event = []
+			for (var i = 0; i < records.length; i++) {
+				event.push({"message": records[i]})
+			}
+			emit({"message": "summary", "count": records.length})
+		`,
+	}
+	javascript, err := New(JavascriptFilter, config, filterConfig, internalCache, alerts)
+	if err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+
+	event := &common.Event{
+		Timestamp: time.Now(),
+		Input:     "Testing",
+		Data: map[string]interface{}{
+			"message": "first,second,third",
+		},
+	}
+
+	events, err := Apply(javascript, event)
+	if err != nil {
+		t.Fatalf("Something is very very wrong. %s", err.Error())
+	}
+
+	if len(events) != 4 || events[0].Data["message"] != "first" || events[2].Data["message"] != "third" || events[3].Data["count"].(float64) != 3 {
+		t.Fatal("javascript filter did not split the event into the array elements and emitted events.")
+	}
+	if events[0].Input != "Testing" || events[3].Timestamp != event.Timestamp {
+		t.Fatal("javascript filter split events without the originating timestamp and input.")
+	}
+
+	test, err := javascript.Run(event)
+	if err == nil || test != event {
+		t.Fatal("javascript filter did not error when Run was used on a filter that splits events.")
+	}
+
+	events, err = Apply(javascript, &common.Event{Data: map[string]interface{}{"message": "single"}})
+	if err != nil {
+		t.Fatalf("Something is very very wrong. %s", err.Error())
+	}
+	if len(events) != 2 || events[1].Data["count"].(float64) != 1 {
+		t.Fatal("javascript filter leaked emitted events between runs.")
+	}
+}
+
+func TestJavascriptSplitImproperElement(t *testing.T) {
+	filterConfig := &common.FilterConfig{
+		Name: "Test Filter",
+		Code: `
+			event = [{"message": "woot"}, "testing"]
+		`,
+	}
+	javascript, err := New(JavascriptFilter, config, filterConfig, internalCache, alerts)
+	if err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+
+	events, err := Apply(javascript, &common.Event{Data: map[string]interface{}{"message": "woot"}})
+	if err == nil || events != nil {
+		t.Fatal("javascript filter improperly set an array element that isn't an object but passed.")
+	}
+}
+
+func TestApply(t *testing.T) {
+	noop, _ := New(NoopFilter, nil, nil, nil, nil)
+	event := &common.Event{Data: map[string]interface{}{"message": "woot"}}
+
+	events, err := Apply(noop, event)
+	if err != nil || len(events) != 1 || events[0] != event {
+		t.Fatal("Apply did not wrap the result of a single event filter.")
+	}
+}
+
+func TestJavascriptTagAndRoute(t *testing.T) {
+	filterConfig := &common.FilterConfig{
+		Name: "Test Filter",
+		Code: `
+			tag("audit", "security")
+			route("audit-sink")
+			emit({"message": "copy"})
+		`,
+	}
+	javascript, err := New(JavascriptFilter, config, filterConfig, internalCache, alerts)
+	if err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+
+	event := &common.Event{
+		Timestamp: time.Now(),
+		Tags:      []string{"audit"},
+		Data: map[string]interface{}{
+			"message": 101010101,
+		},
+	}
+
+	events, err := Apply(javascript, event)
+	if err != nil {
+		t.Fatalf("Something is very very wrong. %s", err.Error())
+	}
+
+	for _, test := range events {
+		if len(test.Tags) != 2 || !test.HasTag("audit") || !test.HasTag("security") {
+			t.Fatal("javascript filter did not tag the resulting events.")
+		}
+		if len(test.Routes) != 1 || test.Routes[0] != "audit-sink" {
+			t.Fatal("javascript filter did not route the resulting events.")
+		}
+	}
+}
+
+func TestJavascriptMeta(t *testing.T) {
+	filterConfig := &common.FilterConfig{
+		Name: "Test Filter",
+		Code: `
+			event.input = meta.input
+			event.worker = meta.worker
+			event.epoch_ms = meta.epoch_ms
+			event.received = meta.received
+			meta.timestamp = event.logged_at
+		`,
+	}
+	javascript, err := New(JavascriptFilter, config, filterConfig, internalCache, alerts)
+	if err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+
+	now := time.Now()
+	event := &common.Event{
+		Timestamp: now,
+		Received:  now,
+		Input:     "Testing",
+		Worker:    3,
+		Data: map[string]interface{}{
+			"logged_at": "2017-03-06T12:30:45.123Z",
+		},
+	}
+
+	test, err := javascript.Run(event)
+	if err != nil {
+		t.Fatalf("Something is very very wrong. %s", err.Error())
+	}
+
+	if test.Data["input"] != "Testing" || test.Data["worker"].(float64) != 3 || int64(test.Data["epoch_ms"].(float64)) != now.UnixNano()/int64(time.Millisecond) || test.Data["received"] != now.UTC().Format(time.RFC3339Nano) {
+		t.Fatal("javascript filter did not expose the event metadata.")
+	}
+
+	expected, _ := time.Parse(time.RFC3339Nano, "2017-03-06T12:30:45.123Z")
+	if !test.Timestamp.Equal(expected) {
+		t.Fatal("javascript filter did not overwrite the event timestamp from 'meta.timestamp'.")
+	}
+
+	filterConfig.Code = `meta.epoch_ms = 1000`
+	javascript, _ = New(JavascriptFilter, config, filterConfig, internalCache, alerts)
+
+	test, err = javascript.Run(event)
+	if err != nil {
+		t.Fatalf("Something is very very wrong. %s", err.Error())
+	}
+	if !test.Timestamp.Equal(time.Unix(1, 0)) {
+		t.Fatal("javascript filter did not overwrite the event timestamp from 'meta.epoch_ms'.")
+	}
+
+	filterConfig.Code = `meta.timestamp = "not a timestamp"`
+	javascript, _ = New(JavascriptFilter, config, filterConfig, internalCache, alerts)
+
+	test, err = javascript.Run(event)
+	if err == nil || !test.Timestamp.Equal(time.Unix(1, 0)) {
+		t.Fatal("javascript filter improperly set 'meta.timestamp' but passed.")
+	}
+}
+
+func TestJavascriptConfig(t *testing.T) {
+	filterConfig := &common.FilterConfig{
+		Name:   "Test Filter",
+		Code:   `event.threshold = parseInt(config.threshold); config.threshold = "changed"`,
+		Config: map[string]string{"threshold": "5"},
+	}
+	javascript, err := New(JavascriptFilter, config, filterConfig, internalCache, alerts)
+	if err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+
+	test, err := javascript.Run(&common.Event{Data: map[string]interface{}{"message": "woot"}})
+	if err != nil {
+		t.Fatalf("Something is very very wrong. %s", err.Error())
+	}
+
+	if test.Data["threshold"].(float64) != 5 || filterConfig.Config["threshold"] != "5" {
+		t.Fatal("javascript filter did not expose a copy of the filter parameters as 'config'.")
+	}
+}
+
+func TestJSON(t *testing.T) {
+	filterConfig := &common.FilterConfig{Name: "Test JSON", Config: map[string]string{"remove_source": "true"}}
+	parse, err := New(JSONFilter, config, filterConfig, nil, nil)
+	if err != nil {
+		t.Fatalf("Something is very very wrong. %s", err.Error())
+	}
+
+	test, err := parse.Run(&common.Event{Data: map[string]interface{}{"message": `{"level": "info", "sub": {"value": 1}}`}})
+	if err != nil {
+		t.Fatalf("Something is very very wrong. %s", err.Error())
+	}
+	if test.Data["level"] != "info" || test.Data["sub"].(map[string]interface{})["value"].(float64) != 1 {
+		t.Fatal("json filter did not merge the parsed fields into the event.")
+	}
+	if _, ok := test.Data["message"]; ok {
+		t.Fatal("json filter did not remove the source field.")
+	}
+
+	test, err = parse.Run(&common.Event{Data: map[string]interface{}{"message": "not json"}})
+	if err != nil || test.Data["message"] != "not json" || !test.HasTag(JSONFailureTag) {
+		t.Fatal("json filter did not tag the event that failed to parse.")
+	}
+
+	_, err = New(JSONFilter, config, &common.FilterConfig{Name: "Bad", Config: map[string]string{"remove_source": "woot"}}, nil, nil)
+	if err == nil {
+		t.Fatal("json filter accepted an invalid remove_source definition.")
+	}
+}
+
+func TestKV(t *testing.T) {
+	filterConfig := &common.FilterConfig{Name: "Test KV", Config: map[string]string{"target": "pairs"}}
+	parse, err := New(KVFilter, config, filterConfig, nil, nil)
+	if err != nil {
+		t.Fatalf("Something is very very wrong. %s", err.Error())
+	}
+
+	test, err := parse.Run(&common.Event{Data: map[string]interface{}{"message": `user=bob action="log in" status=ok junk`}})
+	if err != nil {
+		t.Fatalf("Something is very very wrong. %s", err.Error())
+	}
+
+	pairs, ok := test.Data["pairs"].(map[string]interface{})
+	if !ok || len(pairs) != 3 || pairs["user"] != "bob" || pairs["action"] != "log in" || pairs["status"] != "ok" {
+		t.Fatal("kv filter did not parse the key value pairs into the target field.")
+	}
+
+	filterConfig = &common.FilterConfig{Name: "Test KV", Config: map[string]string{"field_split": "&", "value_split": ":", "prefix": "q_"}}
+	parse, _ = New(KVFilter, config, filterConfig, nil, nil)
+	test, _ = parse.Run(&common.Event{Data: map[string]interface{}{"message": "a:1&b:2"}})
+	if test.Data["q_a"] != "1" || test.Data["q_b"] != "2" {
+		t.Fatal("kv filter did not honour the configured separators and prefix.")
+	}
+
+	test, _ = parse.Run(&common.Event{Data: map[string]interface{}{"message": 42}})
+	if !test.HasTag(KVFailureTag) {
+		t.Fatal("kv filter did not tag the event with a non string source field.")
+	}
+}
+
+func TestGrok(t *testing.T) {
+	cases := []struct {
+		match  string
+		text   string
+		fields map[string]interface{}
+	}{
+		{
+			match:  "%{SYSLOGLINE}",
+			text:   "Oct 11 22:14:15 mymachine sshd[4123]: Accepted publickey for bob",
+			fields: map[string]interface{}{"timestamp": "Oct 11 22:14:15", "logsource": "mymachine", "program": "sshd", "pid": int64(4123), "message": "Accepted publickey for bob"},
+		},
+		{
+			match:  "%{COMBINEDAPACHELOG}",
+			text:   `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif?a=b HTTP/1.0" 200 2326 "http://www.example.com/start.html" "Mozilla/4.08"`,
+			fields: map[string]interface{}{"clientip": "127.0.0.1", "auth": "frank", "verb": "GET", "request": "/apache_pb.gif?a=b", "response": int64(200), "bytes": int64(2326), "agent": `"Mozilla/4.08"`},
+		},
+		{
+			match:  "%{NGINXACCESS}",
+			text:   `10.0.0.1 - - [17/Oct/2017:10:00:00 +0000] "POST /api HTTP/1.1" 502 - "-" "curl/7.0"`,
+			fields: map[string]interface{}{"clientip": "10.0.0.1", "verb": "POST", "request": "/api", "response": int64(502), "agent": `"curl/7.0"`},
+		},
+		{
+			match:  "%{NGINXERROR}",
+			text:   "2017/10/17 10:00:00 [error] 1234#0: *5 connect() failed",
+			fields: map[string]interface{}{"timestamp": "2017/10/17 10:00:00", "loglevel": "error", "pid": int64(1234), "connection": int64(5), "message": "connect() failed"},
+		},
+		{
+			match:  `%{WORD:action} took %{NUMBER:duration:float}s (?P<rest>.*)`,
+			text:   "request took 1.5s in total",
+			fields: map[string]interface{}{"action": "request", "duration": 1.5, "rest": "in total"},
+		},
+	}
+
+	for _, c := range cases {
+		grok, err := New(GrokFilter, config, &common.FilterConfig{Name: "Test Grok", Config: map[string]string{"match": c.match}}, nil, nil)
+		if err != nil {
+			t.Fatalf("Something is very very wrong. %s", err.Error())
+		}
+
+		test, err := grok.Run(&common.Event{Data: map[string]interface{}{"message": c.text}})
+		if err != nil || test.HasTag(GrokFailureTag) {
+			t.Fatalf("grok filter failed to match '%s' against '%s'.", c.match, c.text)
+		}
+
+		for field, expected := range c.fields {
+			if test.Data[field] != expected {
+				t.Fatalf("grok filter set field '%s' to '%v' expected '%v' for '%s'.", field, test.Data[field], expected, c.match)
+			}
+		}
+	}
+}
+
+func TestGrokConfig(t *testing.T) {
+	filterConfig := &common.FilterConfig{
+		Name: "Test Grok",
+		Config: map[string]string{
+			"match":          "%{ORDER:order.id}",
+			"pattern_ORDER":  "ORD-%{INT}",
+			"source":         "line",
+			"tag_on_failure": "unmatched",
+		},
+	}
+	grok, err := New(GrokFilter, config, filterConfig, nil, nil)
+	if err != nil {
+		t.Fatalf("Something is very very wrong. %s", err.Error())
+	}
+
+	test, _ := grok.Run(&common.Event{Data: map[string]interface{}{"line": "placed ORD-42"}})
+	if value, ok := test.Field("order.id"); !ok || value != "ORD-42" {
+		t.Fatal("grok filter did not use the custom pattern, source, or nested field.")
+	}
+
+	test, _ = grok.Run(&common.Event{Data: map[string]interface{}{"line": "nothing here"}})
+	if !test.HasTag("unmatched") || test.HasTag(GrokFailureTag) {
+		t.Fatal("grok filter did not use the configured failure tag.")
+	}
+
+	bad := []map[string]string{
+		{},
+		{"match": "%{MISSING}"},
+		{"match": "%{LOOP}", "pattern_LOOP": "%{LOOP}"},
+		{"match": "("},
+		{"match": "(?P<_grok5>.*)"},
+		{"match": "%{WORD:word} (?P<_grok0>.*)"},
+		{"match": "(?P<_grokname>.*)"},
+	}
+	for _, cfg := range bad {
+		if _, err := New(GrokFilter, config, &common.FilterConfig{Name: "Bad", Config: cfg}, nil, nil); err == nil {
+			t.Fatalf("grok filter accepted the invalid configuration: %v", cfg)
+		}
+	}
+}
+
+func TestMutate(t *testing.T) {
+	filterConfig := &common.FilterConfig{
+		Name: "Test Mutate",
+		Config: map[string]string{
+			"default_env":      "production",
+			"default_level":    "info",
+			"rename_host":      "source.host",
+			"copy_source.host": "origin",
+			"convert_status":   "int",
+			"convert_latency":  "float",
+			"convert_ok":       "bool",
+			"convert_code":     "string",
+			"convert_bad":      "int",
+			"split_tags":       ",",
+			"join_parts":       "-",
+			"lowercase":        "level, tags",
+			"uppercase":        "method",
+			"remove":           "secret, nested.secret",
+		},
+	}
+	mutate, err := New(MutateFilter, config, filterConfig, nil, nil)
+	if err != nil {
+		t.Fatalf("Something is very very wrong. %s", err.Error())
+	}
+
+	test, err := mutate.Run(&common.Event{Data: map[string]interface{}{
+		"level":   "WARN",
+		"host":    "web-1",
+		"status":  "200",
+		"latency": "1.5",
+		"ok":      "true",
+		"code":    float64(404),
+		"bad":     "woot",
+		"tags":    "A,B",
+		"parts":   []interface{}{"x", float64(1)},
+		"method":  "get",
+		"secret":  "hunter2",
+		"nested":  map[string]interface{}{"secret": "hunter2", "keep": true},
+	}})
+	if err != nil {
+		t.Fatalf("Something is very very wrong. %s", err.Error())
+	}
+
+	if test.Data["env"] != "production" || test.Data["level"] != "warn" {
+		t.Fatal("mutate filter did not apply the defaults and lowercase operations.")
+	}
+	if value, ok := test.Field("source.host"); !ok || value != "web-1" || test.Data["origin"] != "web-1" || test.Data["host"] != nil {
+		t.Fatal("mutate filter did not rename and copy the nested field.")
+	}
+	if test.Data["status"] != int64(200) || test.Data["latency"] != 1.5 || test.Data["ok"] != true || test.Data["code"] != "404" {
+		t.Fatal("mutate filter did not convert the fields.")
+	}
+	if test.Data["bad"] != "woot" || !test.HasTag(MutateFailureTag) {
+		t.Fatal("mutate filter did not tag the event with a field that failed to convert.")
+	}
+	if tags := test.Data["tags"].([]interface{}); len(tags) != 2 || tags[0] != "a" || tags[1] != "b" {
+		t.Fatal("mutate filter did not split and lowercase the field.")
+	}
+	if test.Data["parts"] != "x-1" || test.Data["method"] != "GET" {
+		t.Fatal("mutate filter did not apply the join and uppercase operations.")
+	}
+	if _, ok := test.Data["secret"]; ok {
+		t.Fatal("mutate filter did not remove the field.")
+	}
+	if nested := test.Data["nested"].(map[string]interface{}); len(nested) != 1 {
+		t.Fatal("mutate filter did not remove the nested field.")
+	}
+
+	bad := []map[string]string{
+		{"convert_status": "woot"},
+		{"rename_host": ""},
+		{"split_tags": ""},
+	}
+	for _, cfg := range bad {
+		if _, err := New(MutateFilter, config, &common.FilterConfig{Name: "Bad", Config: cfg}, nil, nil); err == nil {
+			t.Fatalf("mutate filter accepted the invalid configuration: %v", cfg)
+		}
+	}
+}
+
+func TestMutateRename(t *testing.T) {
+	filterConfig := &common.FilterConfig{
+		Name: "Test Mutate",
+		Config: map[string]string{
+			"rename_host":   "source.host",
+			"rename_user":   "user.name",
+			"rename_conn.a": "conn",
+		},
+	}
+	mutate, err := New(MutateFilter, config, filterConfig, nil, nil)
+	if err != nil {
+		t.Fatalf("Something is very very wrong. %s", err.Error())
+	}
+
+	test, err := mutate.Run(&common.Event{Data: map[string]interface{}{
+		"host":   "web-1",
+		"source": "syslog",
+		"user":   "bob",
+		"conn":   map[string]interface{}{"a": "10.0.0.1"},
+	}})
+	if err != nil {
+		t.Fatalf("Something is very very wrong. %s", err.Error())
+	}
+
+	if test.Data["host"] != "web-1" || test.Data["source"] != "syslog" {
+		t.Fatal("mutate filter lost the value of a field renamed to a destination blocked by a field that isn't an object.")
+	}
+	if value, ok := test.Field("user.name"); !ok || value != "bob" {
+		t.Fatal("mutate filter did not rename the field to its own child.")
+	}
+	if test.Data["conn"] != "10.0.0.1" {
+		t.Fatal("mutate filter did not rename the field to its own parent.")
+	}
+}
+
+func TestMutateOrder(t *testing.T) {
+	filterConfig := &common.FilterConfig{
+		Name: "Test Mutate",
+		Config: map[string]string{
+			"remove":         "level",
+			"default_level":  "info",
+			"convert_status": "int",
+			"copy_status":    "code",
+			"convert_count":  "int",
+			"convert_ratio":  "float",
+			"convert_ok":     "bool",
+		},
+		ConfigKeys: []string{"remove", "default_level", "convert_status", "copy_status"},
+	}
+	mutate, err := New(MutateFilter, config, filterConfig, nil, nil)
+	if err != nil {
+		t.Fatalf("Something is very very wrong. %s", err.Error())
+	}
+
+	test, err := mutate.Run(&common.Event{Data: map[string]interface{}{
+		"level":  "warn",
+		"status": "200",
+		"count":  10,
+		"ratio":  3,
+		"ok":     0,
+	}})
+	if err != nil {
+		t.Fatalf("Something is very very wrong. %s", err.Error())
+	}
+
+	if test.Data["level"] != "info" {
+		t.Fatal("mutate filter did not apply the remove and default operations in the configured order.")
+	}
+	if test.Data["status"] != int64(200) || test.Data["code"] != int64(200) {
+		t.Fatal("mutate filter did not apply the convert and copy operations in the configured order.")
+	}
+	if test.Data["count"] != int64(10) || test.Data["ratio"] != float64(3) || test.Data["ok"] != false || test.HasTag(MutateFailureTag) {
+		t.Fatal("mutate filter did not convert fields holding an int.")
+	}
+}
+
+func TestDate(t *testing.T) {
+	expected := time.Date(2017, time.October, 11, 22, 14, 15, 0, time.UTC)
+	cases := []struct {
+		layouts string
+		value   interface{}
+	}{
+		{layouts: "", value: "2017-10-11T22:14:15Z"},
+		{layouts: "RFC3339", value: "2017-10-11T18:14:15-04:00"},
+		{layouts: "UNIX", value: float64(expected.Unix())},
+		{layouts: "UNIX", value: strconv.FormatInt(expected.Unix(), 10)},
+		{layouts: "UNIX_MS", value: float64(expected.Unix() * 1000)},
+		{layouts: "HTTPDATE", value: "11/Oct/2017:22:14:15 +0000"},
+		{layouts: "RFC3339 | 2006/01/02 15:04:05", value: "2017/10/11 22:14:15"},
+		{layouts: "ISO8601", value: "2017-10-11 22:14:15"},
+	}
+
+	for _, c := range cases {
+		date, err := New(DateFilter, config, &common.FilterConfig{Name: "Test Date", Config: map[string]string{"layouts": c.layouts}}, nil, nil)
+		if err != nil {
+			t.Fatalf("Something is very very wrong. %s", err.Error())
+		}
+
+		test, err := date.Run(&common.Event{Data: map[string]interface{}{"timestamp": c.value}})
+		if err != nil || test.HasTag(DateFailureTag) || !test.Timestamp.Equal(expected) {
+			t.Fatalf("date filter parsed '%v' with layouts '%s' as '%s'.", c.value, c.layouts, test.Timestamp)
+		}
+	}
+}
+
+func TestDateConfig(t *testing.T) {
+	filterConfig := &common.FilterConfig{
+		Name:   "Test Date",
+		Config: map[string]string{"field": "log.time", "layouts": "SYSLOG", "timezone": "America/New_York"},
+	}
+	date, err := New(DateFilter, config, filterConfig, nil, nil)
+	if err != nil {
+		t.Fatalf("Something is very very wrong. %s", err.Error())
+	}
+
+	location, _ := time.LoadLocation("America/New_York")
+	now := time.Now().In(location)
+	test, _ := date.Run(&common.Event{Data: map[string]interface{}{"log": map[string]interface{}{"time": now.Format(time.Stamp)}}})
+	if test.HasTag(DateFailureTag) || test.Timestamp.Unix() != now.Unix() {
+		t.Fatalf("date filter parsed the syslog timestamp as '%s' expected '%s'.", test.Timestamp, now)
+	}
+
+	original := time.Now()
+	test, _ = date.Run(&common.Event{Timestamp: original, Data: map[string]interface{}{"log": map[string]interface{}{"time": "not a date"}}})
+	if !test.HasTag(DateFailureTag) || !test.Timestamp.Equal(original) {
+		t.Fatal("date filter did not tag the event that failed to parse.")
+	}
+
+	if _, err := New(DateFilter, config, &common.FilterConfig{Name: "Bad", Config: map[string]string{"timezone": "Not/AZone"}}, nil, nil); err == nil {
+		t.Fatal("date filter accepted an invalid timezone.")
+	}
+}
+
+func TestGuard(t *testing.T) {
+	filterConfig := &common.FilterConfig{
+		Name:   "Test Guard",
+		Code:   `event = [{"part": 1}, {"part": 2}]`,
+		If:     `level == "error"`,
+		Config: map[string]string{},
+	}
+	guarded, err := New(JavascriptFilter, config, filterConfig, internalCache, alerts)
+	if err != nil {
+		t.Fatalf("Something is very very wrong. %s", err.Error())
+	}
+	if guarded.Name() != "Test Guard" {
+		t.Fatal("guarded filter did not keep the name of the wrapped filter.")
+	}
+
+	event := &common.Event{Data: map[string]interface{}{"level": "info"}}
+	events, err := Apply(guarded, event)
+	if err != nil || len(events) != 1 || events[0] != event {
+		t.Fatal("guarded filter did not pass through the event that doesn't satisfy its condition.")
+	}
+
+	events, err = Apply(guarded, &common.Event{Data: map[string]interface{}{"level": "error"}})
+	if err != nil || len(events) != 2 {
+		t.Fatal("guarded filter did not run the wrapped filter on the event that satisfies its condition.")
+	}
+
+	filterConfig = &common.FilterConfig{Name: "Test Guard", If: `level == "error"`, Config: map[string]string{"uppercase": "level"}}
+	guarded, _ = New(MutateFilter, config, filterConfig, nil, nil)
+	test, _ := guarded.Run(&common.Event{Data: map[string]interface{}{"level": "error"}})
+	if test.Data["level"] != "ERROR" {
+		t.Fatal("guarded filter did not run the wrapped native filter on the event that satisfies its condition.")
+	}
+
+	filterConfig.If = `level ==`
+	if _, err := New(MutateFilter, config, filterConfig, nil, nil); err == nil {
+		t.Fatal("guarded filter accepted an invalid if condition.")
+	}
+}
+
+func TestJavascriptInternalCacheQueries(t *testing.T) {
+	queryCache, _ := cache.New(cache.MemoryCache, config, &common.PluginConfig{Name: "memory"})
+	defer queryCache.Close()
+
+	filterConfig := &common.FilterConfig{
+		Name: "Test Filter",
+		Code: `
+			cache.store("failed:" + event.user, event);
+			event.attempts = cache.incr("attempts:" + event.user, 1, "10m");
+			event.failures = cache.len("failed:" + event.user);
+			event.recent = cache.getSince("failed:" + event.user, new Date(Date.now() - 600000)).length;
+			event.users = cache.keys("failed:");
+			if (event.failures >= 3) {
+				cache.delete("failed:" + event.user);
+			}
+		`,
+	}
+	javascript, err := New(JavascriptFilter, config, filterConfig, queryCache, alerts)
+	if err != nil {
+		t.Fatalf("Something is very very wrong. %s", err.Error())
+	}
+
+	queryCache.Store("failed:bob", &common.Event{Timestamp: time.Now().Add(-time.Hour), Data: map[string]interface{}{}})
+	queryCache.Store("failed:alice", &common.Event{Timestamp: time.Now(), Data: map[string]interface{}{}})
+
+	var test *common.Event
+	for i := 0; i < 2; i++ {
+		test, err = javascript.Run(&common.Event{Timestamp: time.Now(), Data: map[string]interface{}{"user": "bob"}})
+		if err != nil {
+			t.Fatalf("Something is very very wrong. %s", err.Error())
+		}
+	}
+
+	if test.Data["attempts"].(float64) != 2 || test.Data["failures"].(float64) != 3 || test.Data["recent"].(float64) != 2 {
+		t.Fatalf("javascript filter did not expose the cache counters and windowed queries: %v", test.Data)
+	}
+	if users := test.Data["users"].([]interface{}); len(users) != 2 || users[0] != "failed:alice" || users[1] != "failed:bob" {
+		t.Fatal("javascript filter did not expose the cache keys.")
+	}
+	if queryCache.Len("failed:bob") != 0 {
+		t.Fatal("javascript filter did not expose the cache delete.")
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright (c) 2017 Christian Saide <Supernomad>
+// Licensed under the MPL-2.0, for details see https://github.com/Supernomad/protond/blob/master/LICENSE
+
+package filter
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Supernomad/protond/common"
+)
+
+const (
+	// GrokFailureTag is the default tag added to events whose source field did not match the configured grok pattern.
+	GrokFailureTag = "_grokparsefailure"
+
+	grokPatternPrefix = "pattern_"
+	grokCapturePrefix = "_grok"
+	grokMaxDepth      = 32
+)
+
+// grokReference matches pattern references of the form '%{NAME}', '%{NAME:field}' or '%{NAME:field:type}'.
+var grokReference = regexp.MustCompile(`%\{(\w+)(?::([\w.@-]+))?(?::(int|float))?\}`)
+
+type grokField struct {
+	name      string
+	valueType string
+}
+
+// Grok is a struct representing the grok parsing filter plugin.
+type Grok struct {
+	config       *common.Config
+	filterConfig *common.FilterConfig
+	parser       *parser
+	regex        *regexp.Regexp
+	fields       []*grokField
+	captures     []*grokField
+}
+
+// expand recursively replaces the pattern references in the supplied pattern with their definitions, references naming a field are turned into named capture groups.
+func (grok *Grok) expand(pattern string, patterns map[string]string, depth int) (string, error) {
+	if depth > grokMaxDepth {
+		return "", errors.New("grok pattern references are nested too deeply, check for recursive pattern definitions")
+	}
+
+	var err error
+	expanded := grokReference.ReplaceAllStringFunc(pattern, func(reference string) string {
+		if err != nil {
+			return ""
+		}
+
+		parts := grokReference.FindStringSubmatch(reference)
+		definition, ok := patterns[parts[1]]
+		if !ok {
+			err = errors.New("grok pattern '" + parts[1] + "' is not defined")
+			return ""
+		}
+
+		definition, err = grok.expand(definition, patterns, depth+1)
+		if err != nil {
+			return ""
+		}
+
+		if parts[2] == "" {
+			return "(?:" + definition + ")"
+		}
+
+		grok.fields = append(grok.fields, &grokField{name: parts[2], valueType: parts[3]})
+		return "(?P<" + grokCapturePrefix + strconv.Itoa(len(grok.fields)-1) + ">" + definition + ")"
+	})
+	return expanded, err
+}
+
+// capture maps each subexpression of the compiled pattern to the field it captures, the names of the capture groups generated for pattern references are reserved so that a named group in the pattern can't be mistaken for one of them.
+func (grok *Grok) capture() error {
+	names := grok.regex.SubexpNames()
+	grok.captures = make([]*grokField, len(names))
+
+	seen := make([]bool, len(grok.fields))
+	for i, name := range names {
+		if !strings.HasPrefix(name, grokCapturePrefix) {
+			if name != "" {
+				grok.captures[i] = &grokField{name: name}
+			}
+			continue
+		}
+
+		index, err := strconv.Atoi(strings.TrimPrefix(name, grokCapturePrefix))
+		if err != nil || index < 0 || index >= len(grok.fields) || seen[index] {
+			return errors.New("capture group names starting with '" + grokCapturePrefix + "' are reserved, the name was: " + name)
+		}
+		seen[index] = true
+		grok.captures[i] = grok.fields[index]
+	}
+	return nil
+}
+
+func (field *grokField) convert(value string) interface{} {
+	switch field.valueType {
+	case "int":
+		if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return i
+		}
+	case "float":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return value
+}
+
+// Run matches the configured source field of the event against the grok pattern and merges the captured fields into the event, events that do not match are tagged and passed through unchanged.
+func (grok *Grok) Run(event *common.Event) (*common.Event, error) {
+	text, ok := grok.parser.text(event)
+	if !ok {
+		grok.parser.fail(event)
+		return event, nil
+	}
+
+	match := grok.regex.FindStringSubmatchIndex(text)
+	if match == nil {
+		grok.parser.fail(event)
+		return event, nil
+	}
+
+	fields := make(map[string]interface{})
+	for i, field := range grok.captures {
+		start, end := match[2*i], match[2*i+1]
+		if field == nil || start < 0 {
+			continue
+		}
+		fields[field.name] = field.convert(text[start:end])
+	}
+
+	grok.parser.store(event, fields)
+	return event, nil
+}
+
+// Name returns the configured name for the grok filter.
+func (grok *Grok) Name() string {
+	return grok.filterConfig.Name
+}
+
+func newGrok(config *common.Config, filterConfig *common.FilterConfig) (Filter, error) {
+	parser, err := newParser(filterConfig, GrokFailureTag)
+	if err != nil {
+		return nil, err
+	}
+
+	grok := &Grok{
+		config:       config,
+		filterConfig: filterConfig,
+		parser:       parser,
+		fields:       make([]*grokField, 0),
+	}
+
+	if filterConfig.Config["match"] == "" {
+		return nil, errors.New("configuration for the grok filter, '" + filterConfig.Name + "', is missing a match definition")
+	}
+
+	// Custom patterns are layered on top of the bundled library, so that they can also override the bundled definitions.
+	patterns := make(map[string]string, len(grokPatterns))
+	for name, definition := range grokPatterns {
+		patterns[name] = definition
+	}
+	for key, definition := range filterConfig.Config {
+		if strings.HasPrefix(key, grokPatternPrefix) {
+			patterns[strings.TrimPrefix(key, grokPatternPrefix)] = definition
+		}
+	}
+
+	expanded, err := grok.expand(filterConfig.Config["match"], patterns, 0)
+	if err != nil {
+		return nil, errors.New("configuration for the grok filter, '" + filterConfig.Name + "', has an invalid match definition: " + err.Error())
+	}
+
+	grok.regex, err = regexp.Compile(expanded)
+	if err != nil {
+		return nil, errors.New("configuration for the grok filter, '" + filterConfig.Name + "', has an invalid match definition: " + err.Error())
+	}
+
+	if err := grok.capture(); err != nil {
+		return nil, errors.New("configuration for the grok filter, '" + filterConfig.Name + "', has an invalid match definition: " + err.Error())
+	}
+
+	return grok, nil
+}
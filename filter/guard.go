@@ -0,0 +1,50 @@
+// Copyright (c) 2017 Christian Saide <Supernomad>
+// Licensed under the MPL-2.0, for details see https://github.com/Supernomad/protond/blob/master/LICENSE
+
+package filter
+
+import (
+	"errors"
+
+	"github.com/Supernomad/protond/common"
+	"github.com/Supernomad/protond/expression"
+)
+
+// Guard is a struct that wraps an arbitrary filter plugin, and only runs it on the events satisfying the conditional expression defined in the filters configuration, all other events pass through unchanged.
+type Guard struct {
+	Filter
+
+	condition *expression.Expression
+}
+
+// Run runs the wrapped filter on the supplied event if it satisfies the condition, otherwise the event is returned unchanged.
+func (guard *Guard) Run(event *common.Event) (*common.Event, error) {
+	if !guard.condition.Match(event) {
+		return event, nil
+	}
+	return guard.Filter.Run(event)
+}
+
+// RunMulti applies the wrapped filter to the supplied event if it satisfies the condition, otherwise the event is returned unchanged.
+func (guard *Guard) RunMulti(event *common.Event) ([]*common.Event, error) {
+	if !guard.condition.Match(event) {
+		return []*common.Event{event}, nil
+	}
+	return Apply(guard.Filter, event)
+}
+
+func newGuard(filter Filter, filterConfig *common.FilterConfig) (Filter, error) {
+	if filterConfig == nil || filterConfig.If == "" {
+		return filter, nil
+	}
+
+	condition, err := expression.Compile(filterConfig.If)
+	if err != nil {
+		return nil, errors.New("filter '" + filterConfig.Name + "' has an invalid if condition: " + err.Error())
+	}
+
+	return &Guard{
+		Filter:    filter,
+		condition: condition,
+	}, nil
+}
@@ -4,7 +4,9 @@
 package filter
 
 import (
+	"encoding/json"
 	"errors"
+	"strconv"
 	"strings"
 	"time"
 
@@ -34,10 +36,66 @@ const (
 			strigifiedEvt = JSON.stringify(evt);
 
 			_store(key, strigifiedEvt);
+		},
+		getSince: function(key, since) {
+			if (since instanceof Date) {
+				since = since.getTime();
+			}
+			return _getSince(key, since);
+		},
+		len: function(key) {
+			return _len(key);
+		},
+		keys: function(prefix) {
+			return _keys(prefix === undefined ? "" : prefix);
+		},
+		delete: function(key) {
+			_delete(key);
+		},
+		incr: function(key, n, ttl) {
+			return _incr(key, n === undefined ? 1 : n, ttl === undefined ? 0 : ttl);
+		}
+	};`
+
+	dropInternal = `var drop = function() {
+		event = null;
+	};`
+
+	emitInternal = `var _emitted = [];
+	var emit = function(evt) {
+		_emitted.push(evt);
+	};`
+
+	routeInternal = `var _tags = [];
+	var _routes = [];
+	var tag = function() {
+		for (var i = 0; i < arguments.length; i++) {
+			_tags.push(String(arguments[i]));
+		}
+	};
+	var route = function() {
+		for (var i = 0; i < arguments.length; i++) {
+			_routes.push(String(arguments[i]));
 		}
 	};`
 
-	returnInternal = `JSON.stringify(event);`
+	// setupInternal makes the globals shared by every event read only, so that a filter can't replace them for the events that follow it, only the arrays rebuilt by resetInternal are left writable.
+	setupInternal = `var _forget = function(target, name) {
+		delete target[name];
+	};
+	Object.freeze(alert);
+	Object.freeze(cache);
+	(function(global) {
+		Object.keys(global).forEach(function(name) {
+			if (name !== "_emitted" && name !== "_tags" && name !== "_routes") {
+				Object.defineProperty(global, name, {writable: false});
+			}
+		});
+	})(this);`
+
+	resetInternal = `_emitted = []; _tags = []; _routes = [];`
+
+	returnInternal = `JSON.stringify({event: event, emitted: _emitted, tags: _tags, routes: _routes, meta: meta});`
 )
 
 // Javascript is a struct representing the javascript filter plugin.
@@ -46,39 +104,80 @@ type Javascript struct {
 	filterConfig  *common.FilterConfig
 	alerts        map[string]alert.Alert
 	internalCache cache.Cache
+
+	prelude *otto.Script
+	setup   *otto.Script
+	reset   *otto.Script
+	script  *otto.Script
+	export  *otto.Script
+	vms     chan *javascriptVM
 }
 
-func renderScript(filterCode string) string {
-	return strings.Join([]string{
-		alertInternal,
-		cacheInternal,
-		filterCode,
-		returnInternal,
-	}, "\n")
+// jsIsolated are the objects whose enumerable properties are checked before each event, as filters run inside a function scope only the properties they add to the global object or the builtin objects can outlive an event.
+var jsIsolated = []string{"this", "Object", "Object.prototype", "Array", "Array.prototype", "String", "String.prototype", "Number", "Number.prototype", "Boolean.prototype", "Function.prototype", "Date", "Date.prototype", "RegExp.prototype", "Error.prototype", "Math", "JSON"}
+
+// javascriptVM is a single reusable javascript vm, with the prelude already run, along with the event it is currently filtering.
+type javascriptVM struct {
+	vm        *otto.Otto
+	event     *common.Event
+	snapshots []*javascriptSnapshot
+	forget    otto.Value
+}
+
+// javascriptSnapshot is the set of enumerable properties an object had once the vm was set up.
+type javascriptSnapshot struct {
+	object *otto.Object
+	keys   map[string]bool
 }
 
-func interrupt(js *Javascript, vm *otto.Otto) {
-	time.Sleep(js.config.FilterTimeout)
-	vm.Interrupt <- func() {
-		panic(errHalt)
+// snapshot records the enumerable properties of the isolated objects once the vm is set up, along with the '_forget' function used to remove anything added to them afterwards.
+func (jsvm *javascriptVM) snapshot() error {
+	var err error
+	if jsvm.forget, err = jsvm.vm.Get("_forget"); err != nil {
+		return err
 	}
+
+	for _, name := range jsIsolated {
+		value, err := jsvm.vm.Run(name)
+		if err != nil {
+			return err
+		}
+
+		snapshot := &javascriptSnapshot{object: value.Object(), keys: make(map[string]bool)}
+		for _, key := range snapshot.object.Keys() {
+			snapshot.keys[key] = true
+		}
+		jsvm.snapshots = append(jsvm.snapshots, snapshot)
+	}
+
+	// The event and its metadata are replaced before every run, so there is no need to remove them.
+	jsvm.snapshots[0].keys["event"] = true
+	jsvm.snapshots[0].keys["meta"] = true
+	return nil
 }
 
-// Run will return a parsed object based on the configured javascript filter.
-func (js *Javascript) Run(event *common.Event) (ret *common.Event, err error) {
-	defer func() {
-		// Handle an interrupt to the javascript vm running the filter.
-		if caught := recover(); caught != nil {
-			ret = event
-			err = errors.New("filter '" + js.filterConfig.Name + "' paniced with '" + caught.(error).Error() + "' while parsing event: " + event.String(false))
+// restore removes every enumerable property added since the snapshot was taken, the properties that existed at the time are read only so can't have changed.
+func (jsvm *javascriptVM) restore() error {
+	for _, snapshot := range jsvm.snapshots {
+		for _, key := range snapshot.object.Keys() {
+			if snapshot.keys[key] {
+				continue
+			}
+			if _, err := jsvm.forget.Call(otto.UndefinedValue(), snapshot.object.Value(), key); err != nil {
+				return err
+			}
 		}
-		return
-	}()
+	}
+	return nil
+}
 
-	vm := otto.New()
+func (js *Javascript) newVM() (*javascriptVM, error) {
+	jsvm := &javascriptVM{
+		vm: otto.New(),
+	}
+	vm := jsvm.vm
 
 	vm.Interrupt = make(chan func(), 1)
-	go interrupt(js, vm)
 
 	vm.Set("_alert", func(call otto.FunctionCall) otto.Value {
 		js.config.Log.Debug.Printf("[FILTER] [JS] Filter, '%s', alert plugin function 'emit' called with plugin name, '%s'.", js.filterConfig.Name, call.Argument(0))
@@ -97,32 +196,33 @@ func (js *Javascript) Run(event *common.Event) (ret *common.Event, err error) {
 				return otto.Value{}
 			}
 
-			event.Data = data
-			alert.Emit(event)
+			// The extra params are optional so a missing or malformed value results in nil params rather than an error.
+			strParams, _ := call.Argument(2).ToString()
+			params, err := common.ParseEventData(strParams)
+			if err != nil {
+				js.config.Log.Debug.Printf("[FILTER] [JS] Filter, '%s', called 'alert.emit' without an extra params object.", js.filterConfig.Name)
+				params = nil
+			}
+
+			alert.Emit(jsvm.event.Derive(data), params)
 		}
 		return otto.Value{}
 	})
 
 	vm.Set("_get", func(call otto.FunctionCall) otto.Value {
-		js.config.Log.Debug.Printf("[FILTER] [JS] Filter, '%s', cache plugin function 'get' called with key, '%s'.", js.filterConfig.Name, call.Argument(0))
-
-		key, err := call.Argument(0).ToString()
-		if err != nil || strings.Contains(key, "Object") {
-			js.config.Log.Error.Printf("[FILTER] [JS] Filter, '%s', errored with call to 'cache.get', first argument was not a string.", js.filterConfig.Name)
+		key, ok := js.cacheKey(call, "get")
+		if !ok {
 			return otto.Value{}
 		}
 
 		events := js.internalCache.Get(key)
-		val, _ := vm.ToValue(events)
+		val, _ := call.Otto.ToValue(events)
 		return val
 	})
 
 	vm.Set("_store", func(call otto.FunctionCall) otto.Value {
-		js.config.Log.Debug.Printf("[FILTER] [JS] Filter, '%s', cache plugin function 'store' called with key, '%s', and value, '%s'.", js.filterConfig.Name, call.Argument(0), call.Argument(1))
-
-		key, err := call.Argument(0).ToString()
-		if err != nil || strings.Contains(key, "Object") {
-			js.config.Log.Error.Printf("[FILTER] [JS] Filter, '%s', errored with call to 'cache.store', first argument was not a string.", js.filterConfig.Name)
+		key, ok := js.cacheKey(call, "store")
+		if !ok {
 			return otto.Value{}
 		}
 
@@ -133,26 +233,325 @@ func (js *Javascript) Run(event *common.Event) (ret *common.Event, err error) {
 			return otto.Value{}
 		}
 
-		event.Data = data
-		js.internalCache.Store(key, event)
+		js.internalCache.Store(key, jsvm.event.Derive(data))
+		return otto.Value{}
+	})
+
+	vm.Set("_getSince", func(call otto.FunctionCall) otto.Value {
+		key, ok := js.cacheKey(call, "getSince")
+		if !ok {
+			return otto.Value{}
+		}
+
+		since, err := jsTime(call.Argument(1))
+		if err != nil {
+			js.config.Log.Error.Printf("[FILTER] [JS] Filter, '%s', errored with call to 'cache.getSince', second argument was not a Date, epoch in milliseconds, or ISO 8601 string.", js.filterConfig.Name)
+			return otto.Value{}
+		}
+
+		events := js.internalCache.GetSince(key, since)
+		val, _ := call.Otto.ToValue(events)
+		return val
+	})
+
+	vm.Set("_len", func(call otto.FunctionCall) otto.Value {
+		key, ok := js.cacheKey(call, "len")
+		if !ok {
+			return otto.Value{}
+		}
+
+		val, _ := call.Otto.ToValue(js.internalCache.Len(key))
+		return val
+	})
+
+	vm.Set("_keys", func(call otto.FunctionCall) otto.Value {
+		prefix, ok := js.cacheKey(call, "keys")
+		if !ok {
+			return otto.Value{}
+		}
+
+		val, _ := call.Otto.ToValue(js.internalCache.Keys(prefix))
+		return val
+	})
+
+	vm.Set("_delete", func(call otto.FunctionCall) otto.Value {
+		key, ok := js.cacheKey(call, "delete")
+		if !ok {
+			return otto.Value{}
+		}
+
+		js.internalCache.Delete(key)
 		return otto.Value{}
 	})
 
-	vm.Set("event", event.Data)
-	value, err := vm.Run(renderScript(js.filterConfig.Code))
+	vm.Set("_incr", func(call otto.FunctionCall) otto.Value {
+		key, ok := js.cacheKey(call, "incr")
+		if !ok {
+			return otto.Value{}
+		}
+
+		n, err := call.Argument(1).ToInteger()
+		if err != nil || !call.Argument(1).IsNumber() {
+			js.config.Log.Error.Printf("[FILTER] [JS] Filter, '%s', errored with call to 'cache.incr', second argument was not a number.", js.filterConfig.Name)
+			return otto.Value{}
+		}
+
+		ttl, err := jsDuration(call.Argument(2))
+		if err != nil {
+			js.config.Log.Error.Printf("[FILTER] [JS] Filter, '%s', errored with call to 'cache.incr', third argument was not a number of milliseconds or a duration string.", js.filterConfig.Name)
+			return otto.Value{}
+		}
+
+		val, _ := call.Otto.ToValue(js.internalCache.Incr(key, n, ttl))
+		return val
+	})
+
+	if _, err := vm.Run(js.prelude); err != nil {
+		return nil, err
+	}
+
+	if _, err := vm.Run(js.setup); err != nil {
+		return nil, err
+	}
+
+	if err := jsvm.snapshot(); err != nil {
+		return nil, err
+	}
+
+	return jsvm, nil
+}
+
+// cacheKey returns the key passed as the first argument to the named cache function, logging an error if it isn't a string.
+func (js *Javascript) cacheKey(call otto.FunctionCall, function string) (string, bool) {
+	js.config.Log.Debug.Printf("[FILTER] [JS] Filter, '%s', cache plugin function '%s' called with key, '%s'.", js.filterConfig.Name, function, call.Argument(0))
+
+	key, err := call.Argument(0).ToString()
+	if err != nil || strings.Contains(key, "Object") {
+		js.config.Log.Error.Printf("[FILTER] [JS] Filter, '%s', errored with call to 'cache.%s', first argument was not a string.", js.filterConfig.Name, function)
+		return "", false
+	}
+	return key, true
+}
+
+// jsTime converts a javascript epoch in milliseconds or ISO 8601 string to a time.
+func jsTime(value otto.Value) (time.Time, error) {
+	if value.IsNumber() {
+		ms, err := value.ToInteger()
+		return time.Unix(0, ms*int64(time.Millisecond)), err
+	}
+
+	if value.IsString() {
+		return time.Parse(time.RFC3339Nano, value.String())
+	}
+	return time.Time{}, errors.New("value is not a number or a string")
+}
+
+// jsDuration converts a javascript number of milliseconds or duration string for example '10m' to a duration.
+func jsDuration(value otto.Value) (time.Duration, error) {
+	if value.IsNumber() {
+		ms, err := value.ToInteger()
+		return time.Duration(ms) * time.Millisecond, err
+	}
+
+	if value.IsString() {
+		return time.ParseDuration(value.String())
+	}
+	return 0, errors.New("value is not a number or a string")
+}
+
+// acquire returns an idle vm from the pool, or creates a new one if every pooled vm is currently in use.
+func (js *Javascript) acquire() (*javascriptVM, error) {
+	select {
+	case jsvm := <-js.vms:
+		return jsvm, nil
+	default:
+		return js.newVM()
+	}
+}
+
+// release returns the vm to the pool, dropping it if the pool is already full.
+func (js *Javascript) release(jsvm *javascriptVM) {
+	jsvm.event = nil
+	select {
+	case js.vms <- jsvm:
+	default:
+	}
+}
+
+// exported is the structure returned from the vm after running the filter script.
+type exported struct {
+	Event   interface{}   `json:"event"`
+	Emitted []interface{} `json:"emitted"`
+	Tags    []string      `json:"tags"`
+	Routes  []string      `json:"routes"`
+	Meta    *meta         `json:"meta"`
+}
+
+// meta is the metadata of the event being filtered, which is exposed to the filter as the 'meta' object.
+type meta struct {
+	Timestamp string `json:"timestamp"`
+	EpochMs   int64  `json:"epoch_ms"`
+	Input     string `json:"input"`
+	Worker    int    `json:"worker"`
+	Received  string `json:"received"`
+}
+
+func newMeta(event *common.Event) *meta {
+	return &meta{
+		Timestamp: event.Timestamp.UTC().Format(time.RFC3339Nano),
+		EpochMs:   event.Timestamp.UnixNano() / int64(time.Millisecond),
+		Input:     event.Input,
+		Worker:    event.Worker,
+		Received:  event.Received.UTC().Format(time.RFC3339Nano),
+	}
+}
+
+func (m *meta) toMap() map[string]interface{} {
+	return map[string]interface{}{
+		"timestamp": m.Timestamp,
+		"epoch_ms":  m.EpochMs,
+		"input":     m.Input,
+		"worker":    m.Worker,
+		"received":  m.Received,
+	}
+}
+
+// apply will update the timestamp of the supplied event if the filter overwrote either 'meta.timestamp' or 'meta.epoch_ms', the other metadata is read only.
+func (js *Javascript) apply(event *common.Event, original *meta, updated *meta) error {
+	if updated == nil {
+		return nil
+	}
+
+	switch {
+	case updated.Timestamp != original.Timestamp:
+		timestamp, err := time.Parse(time.RFC3339Nano, updated.Timestamp)
+		if err != nil {
+			return errors.New("meta.timestamp is not a valid ISO 8601 timestamp within the filter '" + js.filterConfig.Name + "', the value was: " + updated.Timestamp)
+		}
+		event.Timestamp = timestamp
+	case updated.EpochMs != original.EpochMs:
+		event.Timestamp = time.Unix(0, updated.EpochMs*int64(time.Millisecond))
+	}
+	return nil
+}
+
+func (js *Javascript) parse(event *common.Event, original *meta, raw string) ([]*common.Event, error) {
+	var out exported
+	if err := json.Unmarshal([]byte(raw), &out); err != nil {
+		return nil, err
+	}
+
+	if err := js.apply(event, original, out.Meta); err != nil {
+		return nil, err
+	}
+
+	// Tags and routes set with 'tag()' and 'route()' apply to every event the filter returns.
+	for i := 0; i < len(out.Tags); i++ {
+		event.AddTag(out.Tags[i])
+	}
+	event.Routes = append(event.Routes, out.Routes...)
+
+	events := make([]*common.Event, 0, 1+len(out.Emitted))
+	switch value := out.Event.(type) {
+	case nil:
+		// The filter set 'event' to null, either directly or by calling 'drop()', so the event is discarded.
+	case map[string]interface{}:
+		event.Data = value
+		events = append(events, event)
+	case []interface{}:
+		for i := 0; i < len(value); i++ {
+			data, ok := value[i].(map[string]interface{})
+			if !ok {
+				return nil, errors.New("event array element " + strconv.Itoa(i) + " is not an object after running javascript filter, ensure that 'event' is always an object or an array of objects within the filter '" + js.filterConfig.Name + "'")
+			}
+			events = append(events, event.Derive(data))
+		}
+	default:
+		return nil, errors.New("event data is no longer an object after running javascript filter, ensure that 'event' is always an object within the filter '" + js.filterConfig.Name + "', the returned value was: " + raw)
+	}
+
+	for i := 0; i < len(out.Emitted); i++ {
+		data, ok := out.Emitted[i].(map[string]interface{})
+		if !ok {
+			return nil, errors.New("value passed to 'emit' is not an object within the filter '" + js.filterConfig.Name + "'")
+		}
+		events = append(events, event.Derive(data))
+	}
+
+	return events, nil
+}
+
+// RunMulti will return the list of parsed objects based on the configured javascript filter, which will contain more than one event if the filter set 'event' to an array or called 'emit(obj)'.
+func (js *Javascript) RunMulti(event *common.Event) (ret []*common.Event, err error) {
+	jsvm, err := js.acquire()
 	if err != nil {
-		return event, err
+		return nil, err
+	}
+
+	reusable := false
+	defer func() {
+		// Handle an interrupt to the javascript vm running the filter, an interrupted vm is never returned to the pool.
+		if caught := recover(); caught != nil {
+			ret = nil
+			err = errors.New("filter '" + js.filterConfig.Name + "' paniced with '" + caught.(error).Error() + "' while parsing event: " + event.String(false))
+		}
+
+		if reusable {
+			js.release(jsvm)
+		}
+		return
+	}()
+
+	jsvm.event = event
+	timer := time.AfterFunc(js.config.FilterTimeout, func() {
+		jsvm.vm.Interrupt <- func() {
+			panic(errHalt)
+		}
+	})
+
+	original := newMeta(event)
+
+	// Anything a previous event left behind on the pooled vm is undone before the filter runs.
+	err = jsvm.restore()
+	if err == nil {
+		_, err = jsvm.vm.Run(js.reset)
+	}
+
+	var value otto.Value
+	if err == nil {
+		jsvm.vm.Set("event", event.Data)
+		jsvm.vm.Set("meta", original.toMap())
+		value, err = jsvm.vm.Run(js.script)
+	}
+	if err == nil {
+		value, err = jsvm.vm.Run(js.export)
+	}
+
+	// If the timer already fired the interrupt is queued on the vm, so it can't safely be reused.
+	reusable = timer.Stop()
+
+	if err != nil {
+		return nil, err
 	}
 
-	exported, _ := value.ToString()
+	raw, _ := value.ToString()
+	return js.parse(event, original, raw)
+}
 
-	data, err := common.ParseEventData(exported)
+// Run will return a parsed object based on the configured javascript filter, if the filter splits the event into multiple events an error is returned and RunMulti should be used instead.
+func (js *Javascript) Run(event *common.Event) (*common.Event, error) {
+	events, err := js.RunMulti(event)
 	if err != nil {
-		return event, errors.New("event data is no longer an object after running javascript filter, ensure that 'event' is always an object within the filter '" + js.filterConfig.Name + "', the returned value was: " + exported)
+		return event, err
 	}
 
-	event.Data = data
-	return event, nil
+	switch len(events) {
+	case 0:
+		return nil, nil
+	case 1:
+		return events[0], nil
+	}
+	return event, errors.New("filter '" + js.filterConfig.Name + "' split the event into " + strconv.Itoa(len(events)) + " events, which can only be handled with RunMulti")
 }
 
 // Name returns configured name for the javascript filter.
@@ -165,11 +564,53 @@ func newJavascript(config *common.Config, filterConfig *common.FilterConfig, int
 		alerts = make(map[string]alert.Alert)
 	}
 
+	size := config.NumWorkers
+	if size < 1 {
+		size = 1
+	}
+
 	js := &Javascript{
 		config:        config,
 		filterConfig:  filterConfig,
 		internalCache: internalCache,
 		alerts:        alerts,
+		vms:           make(chan *javascriptVM, size),
+	}
+
+	// Scripts are compiled once up front and shared between all of the pooled vms.
+	compiler := otto.New()
+
+	var err error
+	if js.prelude, err = compiler.Compile("", strings.Join([]string{alertInternal, cacheInternal, dropInternal, emitInternal, routeInternal}, "\n")); err != nil {
+		return nil, err
+	}
+
+	// The filter parameters are copied into each vm, and frozen, so that scripts can't modify the shared configuration.
+	params := filterConfig.Config
+	if params == nil {
+		params = make(map[string]string)
+	}
+
+	buf, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if js.setup, err = compiler.Compile("", "var config = Object.freeze("+string(buf)+");\n"+setupInternal); err != nil {
+		return nil, err
+	}
+
+	if js.reset, err = compiler.Compile("", resetInternal); err != nil {
+		return nil, err
+	}
+
+	// The filter runs inside a function scope, so that the variables and functions it declares don't outlive the event, the wrapper is kept on the first and last lines so that reported line numbers match the filter source.
+	if js.script, err = compiler.Compile(filterConfig.Name, "(function() {"+filterConfig.Code+"\n})();"); err != nil {
+		return nil, errors.New("filter '" + filterConfig.Name + "' failed to compile: " + err.Error())
+	}
+
+	if js.export, err = compiler.Compile("", returnInternal); err != nil {
+		return nil, err
 	}
 
 	return js, nil
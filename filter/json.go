@@ -0,0 +1,56 @@
+// Copyright (c) 2017 Christian Saide <Supernomad>
+// Licensed under the MPL-2.0, for details see https://github.com/Supernomad/protond/blob/master/LICENSE
+
+package filter
+
+import (
+	"encoding/json"
+
+	"github.com/Supernomad/protond/common"
+)
+
+// JSONFailureTag is the default tag added to events whose source field could not be parsed as a json object.
+const JSONFailureTag = "_jsonparsefailure"
+
+// JSON is a struct representing the json parsing filter plugin.
+type JSON struct {
+	config       *common.Config
+	filterConfig *common.FilterConfig
+	parser       *parser
+}
+
+// Run parses the configured source field of the event as a json object and merges the resulting fields into the event, events that fail to parse are tagged and passed through unchanged.
+func (j *JSON) Run(event *common.Event) (*common.Event, error) {
+	text, ok := j.parser.text(event)
+	if !ok {
+		j.parser.fail(event)
+		return event, nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(text), &fields); err != nil || fields == nil {
+		j.parser.fail(event)
+		return event, nil
+	}
+
+	j.parser.store(event, fields)
+	return event, nil
+}
+
+// Name returns the configured name for the json filter.
+func (j *JSON) Name() string {
+	return j.filterConfig.Name
+}
+
+func newJSON(config *common.Config, filterConfig *common.FilterConfig) (Filter, error) {
+	parser, err := newParser(filterConfig, JSONFailureTag)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JSON{
+		config:       config,
+		filterConfig: filterConfig,
+		parser:       parser,
+	}, nil
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2017 Christian Saide <Supernomad>
+// Licensed under the MPL-2.0, for details see https://github.com/Supernomad/protond/blob/master/LICENSE
+
+package filter
+
+import (
+	"strings"
+
+	"github.com/Supernomad/protond/common"
+)
+
+// KVFailureTag is the default tag added to events whose source field did not contain any key value pairs.
+const KVFailureTag = "_kvparsefailure"
+
+// KV is a struct representing the key value parsing filter plugin.
+type KV struct {
+	config       *common.Config
+	filterConfig *common.FilterConfig
+	parser       *parser
+	fieldSplit   string
+	valueSplit   string
+	prefix       string
+}
+
+// split breaks the supplied text on the field separator, while keeping quoted values that contain the separator intact.
+func (kv *KV) split(text string) []string {
+	pairs := make([]string, 0)
+
+	var quote byte
+	start := 0
+	for i := 0; i < len(text); i++ {
+		switch {
+		case quote != 0:
+			if text[i] == quote {
+				quote = 0
+			}
+		case text[i] == '"' || text[i] == '\'':
+			quote = text[i]
+		case strings.HasPrefix(text[i:], kv.fieldSplit):
+			pairs = append(pairs, text[start:i])
+			i += len(kv.fieldSplit) - 1
+			start = i + 1
+		}
+	}
+	return append(pairs, text[start:])
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// Run splits the configured source field of the event into key value pairs and merges them into the event as string fields, events without any pairs are tagged and passed through unchanged.
+func (kv *KV) Run(event *common.Event) (*common.Event, error) {
+	text, ok := kv.parser.text(event)
+	if !ok {
+		kv.parser.fail(event)
+		return event, nil
+	}
+
+	fields := make(map[string]interface{})
+	for _, pair := range kv.split(text) {
+		parts := strings.SplitN(pair, kv.valueSplit, 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		key := strings.TrimSpace(parts[0])
+		if key == "" {
+			continue
+		}
+		fields[kv.prefix+key] = unquote(strings.TrimSpace(parts[1]))
+	}
+
+	if len(fields) == 0 {
+		kv.parser.fail(event)
+		return event, nil
+	}
+
+	kv.parser.store(event, fields)
+	return event, nil
+}
+
+// Name returns the configured name for the kv filter.
+func (kv *KV) Name() string {
+	return kv.filterConfig.Name
+}
+
+func newKV(config *common.Config, filterConfig *common.FilterConfig) (Filter, error) {
+	parser, err := newParser(filterConfig, KVFailureTag)
+	if err != nil {
+		return nil, err
+	}
+
+	kv := &KV{
+		config:       config,
+		filterConfig: filterConfig,
+		parser:       parser,
+		fieldSplit:   " ",
+		valueSplit:   "=",
+		prefix:       filterConfig.Config["prefix"],
+	}
+
+	if raw := filterConfig.Config["field_split"]; raw != "" {
+		kv.fieldSplit = raw
+	}
+
+	if raw := filterConfig.Config["value_split"]; raw != "" {
+		kv.valueSplit = raw
+	}
+
+	return kv, nil
+}
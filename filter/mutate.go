@@ -0,0 +1,315 @@
+// Copyright (c) 2017 Christian Saide <Supernomad>
+// Licensed under the MPL-2.0, for details see https://github.com/Supernomad/protond/blob/master/LICENSE
+
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Supernomad/protond/common"
+)
+
+// MutateFailureTag is the tag added to events that had a field which could not be converted to the configured type.
+const MutateFailureTag = "_mutatefailure"
+
+// mutateKinds are the kinds of operation, in the order they are applied when the order of the parameters isn't known, the kinds taking a field name are parameter prefixes and the others take a list of fields.
+var mutateKinds = []string{"default_", "rename_", "copy_", "convert_", "split_", "join_", "lowercase", "uppercase", "remove"}
+
+type mutateOperation struct {
+	kind     string
+	field    string
+	argument string
+}
+
+// Mutate is a struct representing the declarative field mutation filter plugin.
+type Mutate struct {
+	config       *common.Config
+	filterConfig *common.FilterConfig
+	operations   []*mutateOperation
+}
+
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		obj := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			obj[key] = copyValue(item)
+		}
+		return obj
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = copyValue(item)
+		}
+		return list
+	}
+	return value
+}
+
+func convertValue(value interface{}, valueType string) (interface{}, bool) {
+	if list, ok := value.([]interface{}); ok {
+		converted := make([]interface{}, len(list))
+		for i, item := range list {
+			if converted[i], ok = convertValue(item, valueType); !ok {
+				return value, false
+			}
+		}
+		return converted, true
+	}
+
+	switch valueType {
+	case "string":
+		return fmt.Sprint(value), true
+	case "int":
+		switch v := value.(type) {
+		case float64:
+			return int64(v), true
+		case int64:
+			return v, true
+		case int:
+			return int64(v), true
+		case bool:
+			if v {
+				return int64(1), true
+			}
+			return int64(0), true
+		case string:
+			if i, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+				return i, true
+			}
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				return int64(f), true
+			}
+		}
+	case "float":
+		switch v := value.(type) {
+		case float64:
+			return v, true
+		case int64:
+			return float64(v), true
+		case int:
+			return float64(v), true
+		case bool:
+			if v {
+				return float64(1), true
+			}
+			return float64(0), true
+		case string:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				return f, true
+			}
+		}
+	case "bool":
+		switch v := value.(type) {
+		case bool:
+			return v, true
+		case float64:
+			return v != 0, true
+		case int64:
+			return v != 0, true
+		case int:
+			return v != 0, true
+		case string:
+			if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+				return b, true
+			}
+		}
+	}
+	return value, false
+}
+
+func changeCase(value interface{}, change func(string) string) interface{} {
+	switch v := value.(type) {
+	case string:
+		return change(v)
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = changeCase(item, change)
+		}
+		return list
+	}
+	return value
+}
+
+// Run applies the configured operations to the event in the order their parameters were written in the filter file.
+func (mutate *Mutate) Run(event *common.Event) (*common.Event, error) {
+	for _, op := range mutate.operations {
+		switch op.kind {
+		case "default_":
+			if _, ok := event.Field(op.field); !ok {
+				event.SetField(op.field, op.argument)
+			}
+		case "rename_":
+			rename(event, op)
+		case "copy_":
+			if value, ok := event.Field(op.field); ok {
+				event.SetField(op.argument, copyValue(value))
+			}
+		case "convert_":
+			if value, ok := event.Field(op.field); ok {
+				converted, ok := convertValue(value, op.argument)
+				if !ok {
+					event.AddTag(MutateFailureTag)
+					continue
+				}
+				event.SetField(op.field, converted)
+			}
+		case "split_":
+			value, _ := event.Field(op.field)
+			if str, ok := value.(string); ok {
+				parts := strings.Split(str, op.argument)
+				list := make([]interface{}, len(parts))
+				for i := range parts {
+					list[i] = parts[i]
+				}
+				event.SetField(op.field, list)
+			}
+		case "join_":
+			if value, ok := event.Field(op.field); ok {
+				if list, ok := value.([]interface{}); ok {
+					parts := make([]string, len(list))
+					for i := range list {
+						parts[i] = fmt.Sprint(list[i])
+					}
+					event.SetField(op.field, strings.Join(parts, op.argument))
+				}
+			}
+		case "lowercase":
+			if value, ok := event.Field(op.field); ok {
+				event.SetField(op.field, changeCase(value, strings.ToLower))
+			}
+		case "uppercase":
+			if value, ok := event.Field(op.field); ok {
+				event.SetField(op.field, changeCase(value, strings.ToUpper))
+			}
+		case "remove":
+			event.DeleteField(op.field)
+		}
+	}
+
+	return event, nil
+}
+
+func rename(event *common.Event, op *mutateOperation) {
+	value, ok := event.Field(op.field)
+	if !ok || op.field == op.argument {
+		return
+	}
+
+	// Renaming a field to its own parent or child has to remove the source first, as setting the destination would otherwise replace or nest inside it.
+	if strings.HasPrefix(op.argument, op.field+".") || strings.HasPrefix(op.field, op.argument+".") {
+		event.DeleteField(op.field)
+		event.SetField(op.argument, value)
+		return
+	}
+
+	// The source is only removed once the value is set, so a destination blocked by a field that isn't an object doesn't lose the value.
+	if event.SetField(op.argument, value) {
+		event.DeleteField(op.field)
+	}
+}
+
+// Name returns the configured name for the mutate filter.
+func (mutate *Mutate) Name() string {
+	return mutate.filterConfig.Name
+}
+
+func splitFields(raw string) []string {
+	fields := make([]string, 0)
+	for _, field := range strings.Split(raw, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// mutateKind returns the kind of operation the supplied parameter key configures, or false if the key isn't an operation.
+func mutateKind(key string) (int, bool) {
+	for i, kind := range mutateKinds {
+		if strings.HasSuffix(kind, "_") {
+			if strings.HasPrefix(key, kind) && len(key) > len(kind) {
+				return i, true
+			}
+		} else if key == kind {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// mutateKeys returns the parameter keys in the order they were written in the filter file, keys whose order isn't known follow in the order of their kind and then by name.
+func mutateKeys(filterConfig *common.FilterConfig) []string {
+	keys := make([]string, 0, len(filterConfig.Config))
+	seen := make(map[string]bool, len(filterConfig.Config))
+	for _, key := range filterConfig.ConfigKeys {
+		if _, ok := filterConfig.Config[key]; ok && !seen[key] {
+			keys = append(keys, key)
+			seen[key] = true
+		}
+	}
+
+	unordered := make([]string, 0, len(filterConfig.Config)-len(keys))
+	for key := range filterConfig.Config {
+		if !seen[key] {
+			unordered = append(unordered, key)
+		}
+	}
+	sort.Slice(unordered, func(i, j int) bool {
+		kindI, _ := mutateKind(unordered[i])
+		kindJ, _ := mutateKind(unordered[j])
+		if kindI != kindJ {
+			return kindI < kindJ
+		}
+		return unordered[i] < unordered[j]
+	})
+
+	return append(keys, unordered...)
+}
+
+func newMutate(config *common.Config, filterConfig *common.FilterConfig) (Filter, error) {
+	mutate := &Mutate{
+		config:       config,
+		filterConfig: filterConfig,
+		operations:   make([]*mutateOperation, 0),
+	}
+
+	for _, key := range mutateKeys(filterConfig) {
+		index, ok := mutateKind(key)
+		if !ok {
+			continue
+		}
+
+		kind := mutateKinds[index]
+		if !strings.HasSuffix(kind, "_") {
+			for _, field := range splitFields(filterConfig.Config[key]) {
+				mutate.operations = append(mutate.operations, &mutateOperation{kind: kind, field: field})
+			}
+			continue
+		}
+
+		op := &mutateOperation{kind: kind, field: strings.TrimPrefix(key, kind), argument: filterConfig.Config[key]}
+		switch kind {
+		case "convert_":
+			if op.argument != "int" && op.argument != "float" && op.argument != "bool" && op.argument != "string" {
+				return nil, errors.New("configuration for the mutate filter, '" + filterConfig.Name + "', has an invalid conversion for '" + op.field + "', expected one of 'int', 'float', 'bool', or 'string'")
+			}
+		case "rename_", "copy_":
+			if op.argument == "" {
+				return nil, errors.New("configuration for the mutate filter, '" + filterConfig.Name + "', is missing the destination field for '" + key + "'")
+			}
+		case "split_", "join_":
+			if op.argument == "" {
+				return nil, errors.New("configuration for the mutate filter, '" + filterConfig.Name + "', is missing the separator for '" + key + "'")
+			}
+		}
+		mutate.operations = append(mutate.operations, op)
+	}
+
+	return mutate, nil
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2017 Christian Saide <Supernomad>
+// Licensed under the MPL-2.0, for details see https://github.com/Supernomad/protond/blob/master/LICENSE
+
+package filter
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/Supernomad/protond/common"
+)
+
+// parser holds the configuration shared by the native parsing filters, which read a string from a 'source' field and store the parsed fields either at the root of the event or under a 'target' field.
+type parser struct {
+	source       string
+	target       string
+	removeSource bool
+	failureTag   string
+}
+
+func (p *parser) text(event *common.Event) (string, bool) {
+	value, ok := event.Field(p.source)
+	if !ok {
+		return "", false
+	}
+
+	str, ok := value.(string)
+	return str, ok
+}
+
+func (p *parser) store(event *common.Event, fields map[string]interface{}) {
+	if p.removeSource {
+		event.DeleteField(p.source)
+	}
+
+	if p.target != "" {
+		event.SetField(p.target, fields)
+		return
+	}
+
+	for key, value := range fields {
+		event.SetField(key, value)
+	}
+}
+
+func (p *parser) fail(event *common.Event) {
+	if p.failureTag != "" {
+		event.AddTag(p.failureTag)
+	}
+}
+
+func newParser(filterConfig *common.FilterConfig, failureTag string) (*parser, error) {
+	p := &parser{
+		source:     "message",
+		target:     filterConfig.Config["target"],
+		failureTag: failureTag,
+	}
+
+	if source := filterConfig.Config["source"]; source != "" {
+		p.source = source
+	}
+
+	if raw, ok := filterConfig.Config["tag_on_failure"]; ok {
+		p.failureTag = raw
+	}
+
+	if raw := filterConfig.Config["remove_source"]; raw != "" {
+		var err error
+		p.removeSource, err = strconv.ParseBool(raw)
+		if err != nil {
+			return nil, errors.New("configuration for the filter, '" + filterConfig.Name + "', has an invalid remove_source definition, expected a 'bool'")
+		}
+	}
+
+	return p, nil
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2017 Christian Saide <Supernomad>
+// Licensed under the MPL-2.0, for details see https://github.com/Supernomad/protond/blob/master/LICENSE
+
+package input
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Supernomad/protond/codec"
+	"github.com/Supernomad/protond/common"
+)
+
+// decode converts the supplied frame into events from the named input using the supplied codec, a frame that fails to decode is kept in the 'message' field of a single event tagged with the failure tag of the codec.
+func decode(c codec.Codec, name string, frame []byte) []*common.Event {
+	now := time.Now()
+
+	records, err := c.Decode(frame)
+	if err != nil {
+		event := &common.Event{
+			Timestamp: now,
+			Input:     name,
+			Data: map[string]interface{}{
+				"message": strings.TrimRight(string(frame), "\r\n"),
+			},
+		}
+		if tag := c.FailureTag(); tag != "" {
+			event.AddTag(tag)
+		}
+		return []*common.Event{event}
+	}
+
+	events := make([]*common.Event, len(records))
+	for i, data := range records {
+		events[i] = &common.Event{
+			Timestamp: now,
+			Input:     name,
+			Data:      data,
+		}
+	}
+	return events
+}
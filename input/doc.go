@@ -9,7 +9,23 @@ Protond currently implements the following input plugins:
     - An input plugin that reads from stdin and is used for testing filters and other pieces of functionality of protond.
   - TCP
     - This plugin allows listening on an arbitrary tcp socket, and reads new line terminated strings from the connected clients.
+  - UDP
+    - This plugin allows listening on an arbitrary udp socket, or joining the multicast group set by 'multicast_group' on the optional 'interface', and treats each datagram as a single event. Datagrams are truncated to 'buffer_size' bytes, defaulting to 65536, and 'read_buffer' sets the size of the receive buffer of the socket. When 'json' is set to 'true' datagrams are decoded as json the same way as the http plugin, datagrams that fail to decode are tagged with '_jsonparsefailure'. The 'source' field holds the address of the sender.
+  - Unix
+    - This plugin allows listening on the unix stream socket at 'path', and reads new line terminated strings from the connected clients.
+  - UnixGram
+    - This plugin allows reading from the unix datagram socket at 'path', and treats each datagram as a single event.
+    - Both unix plugins set the permissions of the socket to 'mode', defaulting to '0660', remove a stale socket left behind by a previous process when opened, and remove the socket when closed.
   - Http
-  	- This plugin aloows listening as an http server, and reads json blobs from connected clients POSTing events to it.
+  	- This plugin aloows listening as an http server, and reads json blobs from connected clients POSTing events to it, json values that are not objects such as batched arrays are wrapped in the 'message' field.
+  - File
+    - This plugin follows the files matching a comma separated list of glob patterns, and reads new line terminated strings from them into the 'message' field along side the 'path' they were read from. Read offsets are persisted to 'checkpoint_path', defaulting to '<data dir>/inputs/<name>.offsets', so that lines are neither lost nor repeated across restarts, and files are followed across rotation by rename as well as truncation, a persisted offset is ignored for a file at a new path that is smaller than the file it was persisted for, as the identity of a deleted file can be reused by a new one. Files found on start up without a persisted offset begin at 'start_position', either 'beginning' or 'end' (default).
+  - Syslog
+    - This plugin listens for syslog messages on udp, tcp, or both (default) as set by 'protocol', tcp streams may be new line terminated or octet counted as described by RFC 6587. RFC 5424 and RFC 3164 messages are parsed into the 'priority', 'facility', 'severity', 'version', 'hostname', 'app_name', 'procid', 'msgid', 'structured_data', and 'message' fields, along side the 'source' address of the sender, and the timestamp of the message becomes the timestamp of the event, RFC 3164 timestamps are interpreted in the configured 'timezone' which defaults to UTC. Messages that fail to parse are tagged with '_syslogparsefailure'.
+
+The Stdin, TCP, UDP, Unix, UnixGram, File, and Http plugins decode what they receive with the codec named by the 'codec' configuration key, see the codec package for the available codecs and their options.
+The Http plugin defaults to the 'json' codec, and the others default to the 'plain' codec which puts each line or datagram in the 'message' field, the UDP plugin's 'json' key selects the 'json' codec when no codec is set.
+
+Every input plugin can set the 'pipeline' configuration key to the name of a sub directory of the filter directory, in which case the events it produces are filtered by the filters in that sub directory instead of the default filter chain.
 */
 package input
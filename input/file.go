@@ -0,0 +1,441 @@
+// Copyright (c) 2017 Christian Saide <Supernomad>
+// Licensed under the MPL-2.0, for details see https://github.com/Supernomad/protond/blob/master/LICENSE
+
+package input
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/Supernomad/protond/codec"
+	"github.com/Supernomad/protond/common"
+)
+
+const (
+	fileStartBeginning = "beginning"
+	fileStartEnd       = "end"
+)
+
+// fileCheckpoint is the persisted read offset of a single file, keyed by the identity of the file so that offsets follow files that are renamed by rotation.
+// The path and size of the file are kept alongside the offset, so that a new file reusing the identity of a deleted one can be told apart from a rotated file.
+type fileCheckpoint struct {
+	Path   string `json:"path"`
+	Offset int64  `json:"offset"`
+	Size   int64  `json:"size"`
+}
+
+// fileLine is an event decoded from a line of a followed file, the offset is committed once the event is handed out by Next, lines that decode into no events are queued without an event so that their offset is still committed in order.
+type fileLine struct {
+	event  *common.Event
+	tail   *fileTail
+	offset int64
+}
+
+// fileTail tracks a single file, the read offset is just past the last line queued by its follower, the committed offset is just past the last line handed out by Next, and the size is the size of the file when its follower last checked.
+// A tail outlives its follower, so that a rotated file that is discovered again resumes from the lines already queued rather than the committed offset.
+type fileTail struct {
+	id        string
+	path      string
+	active    bool
+	read      int64
+	committed int64
+	size      int64
+}
+
+// File is a struct representing the file tail input plugin.
+type File struct {
+	config       *common.Config
+	pluginConfig *common.PluginConfig
+	codec        codec.Codec
+	lines        chan *fileLine
+
+	patterns         []string
+	start            string
+	pollInterval     time.Duration
+	discoverInterval time.Duration
+	checkpointPath   string
+
+	checkpointInterval time.Duration
+
+	lock   sync.Mutex
+	tails  map[string]*fileTail
+	loaded map[string]*fileCheckpoint
+
+	stop   chan struct{}
+	closed bool
+	wg     sync.WaitGroup
+}
+
+func fileIdentity(info os.FileInfo) string {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return strconv.FormatUint(uint64(stat.Dev), 10) + ":" + strconv.FormatUint(uint64(stat.Ino), 10)
+	}
+	return ""
+}
+
+// sleep waits for the supplied duration, returning false if the plugin was closed in the meantime.
+func (file *File) sleep(d time.Duration) bool {
+	select {
+	case <-file.stop:
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+// discover starts following every file matching the configured patterns that isn't already being followed, files found on the first pass without a checkpoint start at the configured start position, files appearing later are read from the beginning.
+func (file *File) discover(initial bool) {
+	seen := make(map[string]bool)
+	for _, pattern := range file.patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			file.config.Log.Error.Printf("[FILE] Input, '%s', has an invalid path pattern '%s': %s", file.pluginConfig.Name, pattern, err.Error())
+			continue
+		}
+
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil || info.IsDir() {
+				continue
+			}
+
+			id := fileIdentity(info)
+			seen[id] = true
+
+			file.lock.Lock()
+			tail, ok := file.tails[id]
+			if ok && tail.active {
+				file.lock.Unlock()
+				continue
+			}
+
+			// A rotated file only ever grows, so a file at a new path that is smaller than when it was checkpointed is a new file that reused the identity of a deleted one.
+			checkpoint := file.loaded[id]
+			if checkpoint != nil && checkpoint.Path != match && info.Size() < checkpoint.Size {
+				file.config.Log.Info.Printf("[FILE] Input, '%s', ignored the read offset of '%s' for '%s', as it is a new file that reused the identity of the old one.", file.pluginConfig.Name, checkpoint.Path, match)
+				checkpoint = nil
+				delete(file.loaded, id)
+			}
+
+			var offset int64
+			switch {
+			case ok:
+				offset = atomic.LoadInt64(&tail.read)
+			case checkpoint != nil:
+				offset = checkpoint.Offset
+				delete(file.loaded, id)
+			case initial && file.start == fileStartEnd:
+				offset = info.Size()
+			}
+
+			if offset > info.Size() {
+				offset = 0
+			}
+
+			if !ok {
+				tail = &fileTail{id: id, read: offset, committed: offset}
+				file.tails[id] = tail
+			}
+			tail.path = match
+			tail.active = true
+			atomic.StoreInt64(&tail.size, info.Size())
+			file.lock.Unlock()
+
+			file.config.Log.Debug.Printf("[FILE] Input, '%s', following '%s' from offset %d.", file.pluginConfig.Name, match, offset)
+			file.wg.Add(1)
+			go file.follow(tail, match, offset)
+		}
+	}
+
+	// Forget the files that no longer exist, or that have been rotated away from every pattern, once every line read from them has been handed out.
+	file.lock.Lock()
+	for id, tail := range file.tails {
+		if !tail.active && !seen[id] && atomic.LoadInt64(&tail.committed) == atomic.LoadInt64(&tail.read) {
+			delete(file.tails, id)
+		}
+	}
+	for id := range file.loaded {
+		if !seen[id] {
+			delete(file.loaded, id)
+		}
+	}
+	file.lock.Unlock()
+}
+
+func (file *File) discoverer() {
+	defer file.wg.Done()
+
+	for file.sleep(file.discoverInterval) {
+		file.discover(false)
+	}
+}
+
+// follow reads new lines from the supplied file until it is removed or replaced by rotation, a file that is truncated is read again from the beginning.
+func (file *File) follow(tail *fileTail, filePath string, offset int64) {
+	defer file.wg.Done()
+	defer func() {
+		file.lock.Lock()
+		tail.active = false
+		file.lock.Unlock()
+	}()
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		file.config.Log.Error.Printf("[FILE] Input, '%s', failed to open '%s': %s", file.pluginConfig.Name, filePath, err.Error())
+		return
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		file.config.Log.Error.Printf("[FILE] Input, '%s', failed to seek '%s': %s", file.pluginConfig.Name, filePath, err.Error())
+		return
+	}
+
+	reader := bufio.NewReader(f)
+	partial := ""
+	for {
+		text, err := reader.ReadString('\n')
+		if err == nil {
+			// The line starts where its held back partial text started, not where the rest of it was read from.
+			start := offset - int64(len(partial))
+			offset += int64(len(text))
+			events := decode(file.codec, file.pluginConfig.Name, []byte(partial+text))
+			partial = ""
+			atomic.StoreInt64(&tail.read, offset)
+
+			if len(events) == 0 {
+				select {
+				case file.lines <- &fileLine{tail: tail, offset: offset}:
+				case <-file.stop:
+					return
+				}
+				continue
+			}
+
+			// Only the last event decoded from a line commits the end of the line, so that a restart part way through a line reads all of it again.
+			for i, event := range events {
+				line := &fileLine{event: event, tail: tail, offset: start}
+				if i == len(events)-1 {
+					line.offset = offset
+				}
+				if _, ok := event.Data["path"]; !ok {
+					event.Data["path"] = filePath
+				}
+
+				select {
+				case file.lines <- line:
+				case <-file.stop:
+					return
+				}
+			}
+			continue
+		} else if err != io.EOF {
+			file.config.Log.Error.Printf("[FILE] Input, '%s', failed to read '%s': %s", file.pluginConfig.Name, filePath, err.Error())
+			return
+		}
+
+		// An incomplete line is held back until the rest of it is written.
+		partial += text
+		offset += int64(len(text))
+
+		if !file.sleep(file.pollInterval) {
+			return
+		}
+
+		info, err := f.Stat()
+		if err != nil {
+			return
+		}
+		atomic.StoreInt64(&tail.size, info.Size())
+
+		if info.Size() < offset {
+			file.config.Log.Info.Printf("[FILE] Input, '%s', detected that '%s' was truncated, reading it from the beginning.", file.pluginConfig.Name, filePath)
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return
+			}
+			reader.Reset(f)
+			offset, partial = 0, ""
+			atomic.StoreInt64(&tail.read, 0)
+			atomic.StoreInt64(&tail.committed, 0)
+			continue
+		}
+
+		if info.Size() > offset {
+			continue
+		}
+
+		// Once the file is fully read, stop following it if it has been removed or its path now points at a new file, rotated files still matching a pattern are picked up again by their checkpoint.
+		current, err := os.Stat(filePath)
+		if err != nil || fileIdentity(current) != tail.id {
+			file.config.Log.Debug.Printf("[FILE] Input, '%s', stopped following '%s' as it was removed or rotated.", file.pluginConfig.Name, filePath)
+			return
+		}
+	}
+}
+
+// checkpoint persists the committed offset of every followed file.
+func (file *File) checkpoint() error {
+	file.lock.Lock()
+	checkpoints := make(map[string]*fileCheckpoint, len(file.tails)+len(file.loaded))
+	for id, checkpoint := range file.loaded {
+		checkpoints[id] = checkpoint
+	}
+	for id, tail := range file.tails {
+		checkpoints[id] = &fileCheckpoint{Path: tail.path, Offset: atomic.LoadInt64(&tail.committed), Size: atomic.LoadInt64(&tail.size)}
+	}
+	buf, err := json.Marshal(checkpoints)
+	file.lock.Unlock()
+	if err != nil {
+		return err
+	}
+
+	tmp := file.checkpointPath + ".tmp"
+	if err := ioutil.WriteFile(tmp, buf, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file.checkpointPath)
+}
+
+func (file *File) checkpointer(interval time.Duration) {
+	defer file.wg.Done()
+
+	for file.sleep(interval) {
+		if err := file.checkpoint(); err != nil {
+			file.config.Log.Error.Printf("[FILE] Input, '%s', failed to persist read offsets to '%s': %s", file.pluginConfig.Name, file.checkpointPath, err.Error())
+		}
+	}
+}
+
+// Next will return the next event read from the followed files.
+func (file *File) Next() (*common.Event, error) {
+	for {
+		line := <-file.lines
+		atomic.StoreInt64(&line.tail.committed, line.offset)
+
+		if line.event != nil {
+			return line.event, nil
+		}
+	}
+}
+
+// Name returns the name of the file input plugin.
+func (file *File) Name() string {
+	return file.pluginConfig.Name
+}
+
+// Open will load the persisted read offsets and start following the files matching the configured patterns.
+func (file *File) Open() error {
+	if err := os.MkdirAll(path.Dir(file.checkpointPath), 0755); err != nil {
+		return err
+	}
+
+	if buf, err := ioutil.ReadFile(file.checkpointPath); err == nil {
+		if err := json.Unmarshal(buf, &file.loaded); err != nil {
+			return errors.New("the read offsets for the file input plugin, '" + file.pluginConfig.Name + "', are corrupt: " + err.Error())
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	file.stop = make(chan struct{})
+	file.discover(true)
+
+	file.wg.Add(2)
+	go file.discoverer()
+	go file.checkpointer(file.checkpointInterval)
+
+	return nil
+}
+
+// Close will stop following every file and persist the final read offsets.
+func (file *File) Close() error {
+	file.lock.Lock()
+	if file.stop == nil || file.closed {
+		file.lock.Unlock()
+		return nil
+	}
+	file.closed = true
+	close(file.stop)
+	file.lock.Unlock()
+
+	file.wg.Wait()
+	return file.checkpoint()
+}
+
+func parseInterval(pluginConfig *common.PluginConfig, key string, def time.Duration) (time.Duration, error) {
+	raw := pluginConfig.Config[key]
+	if raw == "" {
+		return def, nil
+	}
+
+	interval, err := time.ParseDuration(raw)
+	if err != nil || interval <= 0 {
+		return 0, errors.New("configuration for the input plugin, '" + pluginConfig.Name + "', has an invalid " + key + ", expected a 'duration' for example: '1s'")
+	}
+	return interval, nil
+}
+
+func newFile(config *common.Config, pluginConfig *common.PluginConfig) (Input, error) {
+	file := &File{
+		config:       config,
+		pluginConfig: pluginConfig,
+		lines:        make(chan *fileLine, config.Backlog),
+		patterns:     make([]string, 0),
+		start:        fileStartEnd,
+		tails:        make(map[string]*fileTail),
+		loaded:       make(map[string]*fileCheckpoint),
+	}
+
+	for _, pattern := range strings.Split(pluginConfig.Config["path"], ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			file.patterns = append(file.patterns, pattern)
+		}
+	}
+	if len(file.patterns) == 0 {
+		return nil, errors.New("configuration for the file input plugin, '" + pluginConfig.Name + "', is missing a path definition")
+	}
+
+	if raw := pluginConfig.Config["start_position"]; raw != "" {
+		if raw != fileStartBeginning && raw != fileStartEnd {
+			return nil, errors.New("configuration for the file input plugin, '" + pluginConfig.Name + "', has an invalid start_position, expected either 'beginning' or 'end'")
+		}
+		file.start = raw
+	}
+
+	var err error
+	if file.pollInterval, err = parseInterval(pluginConfig, "poll_interval", 250*time.Millisecond); err != nil {
+		return nil, err
+	}
+	if file.discoverInterval, err = parseInterval(pluginConfig, "discover_interval", 5*time.Second); err != nil {
+		return nil, err
+	}
+	if file.checkpointInterval, err = parseInterval(pluginConfig, "checkpoint_interval", 5*time.Second); err != nil {
+		return nil, err
+	}
+
+	if file.codec, err = codec.Configured(codec.PlainCodec, config, pluginConfig); err != nil {
+		return nil, err
+	}
+	if _, ok := file.codec.(codec.StreamReader); ok {
+		return nil, errors.New("configuration for the file input plugin, '" + pluginConfig.Name + "', has an invalid codec, the '" + file.codec.Name() + "' codec can't be read line by line")
+	}
+
+	file.checkpointPath = pluginConfig.Config["checkpoint_path"]
+	if file.checkpointPath == "" {
+		file.checkpointPath = path.Join(config.DataDir, "inputs", pluginConfig.Name+".offsets")
+	}
+
+	return file, nil
+}
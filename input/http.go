@@ -6,10 +6,11 @@ package input
 import (
 	"encoding/json"
 	"errors"
-	"fmt"
+	"io/ioutil"
 	"net/http"
 	"time"
 
+	"github.com/Supernomad/protond/codec"
 	"github.com/Supernomad/protond/common"
 )
 
@@ -17,6 +18,7 @@ import (
 type HTTP struct {
 	config       *common.Config
 	pluginConfig *common.PluginConfig
+	codec        codec.Codec
 	messages     chan map[string]interface{}
 }
 
@@ -40,7 +42,7 @@ func (h *HTTP) handleResponseError(err error) {
 func (h *HTTP) handleRequestError(w http.ResponseWriter, requestError error) {
 	h.setHeaders(w)
 	body := response{
-		Message: "Error handling request, POSTed data must be a json blob.",
+		Message: "Error handling request, POSTed data could not be decoded by the '" + h.codec.Name() + "' codec.",
 		Error:   requestError.Error(),
 	}
 	resp, _ := json.Marshal(body)
@@ -67,17 +69,27 @@ func (h *HTTP) handleSuccess(w http.ResponseWriter) {
 func (h *HTTP) handleEvents(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
-	var data map[string]interface{}
-	decoder := json.NewDecoder(r.Body)
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		h.handleRequestError(w, err)
+		return
+	}
 
-	err := decoder.Decode(&data)
+	// With the default json codec batched payloads such as json arrays are wrapped in the 'message' field so that filters can split them into individual events.
+	records, err := h.codec.Decode(body)
 	if err != nil {
-		fmt.Println(err.Error())
 		h.handleRequestError(w, err)
 		return
 	}
 
-	h.messages <- data
+	if len(records) == 0 {
+		h.handleRequestError(w, errors.New("the request body did not contain any events"))
+		return
+	}
+
+	for _, data := range records {
+		h.messages <- data
+	}
 	h.handleSuccess(w)
 }
 
@@ -138,5 +150,10 @@ func newHTTP(config *common.Config, pluginConfig *common.PluginConfig) (Input, e
 		h.pluginConfig.Config["route"] = "/"
 	}
 
+	var err error
+	if h.codec, err = codec.Configured(codec.JSONCodec, config, pluginConfig); err != nil {
+		return nil, err
+	}
+
 	return h, nil
 }
@@ -19,8 +19,23 @@ const (
 	// TCPInput defines an input plugin that takes data from a tcp socket.
 	TCPInput = "tcp"
 
+	// UDPInput defines an input plugin that takes data from a udp socket, treating each datagram as a single event.
+	UDPInput = "udp"
+
+	// UnixInput defines an input plugin that takes data from a unix stream socket.
+	UnixInput = "unix"
+
+	// UnixGramInput defines an input plugin that takes data from a unix datagram socket, treating each datagram as a single event.
+	UnixGramInput = "unixgram"
+
 	// HTTPInput defins an input plugin that taks json data being posted from http clients, which can run with or without TLS.
 	HTTPInput = "http"
+
+	// FileInput defines an input plugin that follows files on disk, persisting its read offsets across restarts.
+	FileInput = "file"
+
+	// SyslogInput defines an input plugin that receives RFC 5424 and RFC 3164 syslog messages over udp and tcp.
+	SyslogInput = "syslog"
 )
 
 // Input is the interface that plugins must adhere to for operation as an input plugin.
@@ -44,11 +59,21 @@ func New(inputPlugin string, config *common.Config, pluginConfig *common.PluginC
 	case NoopInput:
 		return newNoop(config)
 	case StdinInput:
-		return newStdin(config)
+		return newStdin(config, pluginConfig)
 	case TCPInput:
 		return newTCP(config, pluginConfig)
+	case UDPInput:
+		return newUDP(config, pluginConfig)
+	case UnixInput:
+		return newUnix(config, pluginConfig)
+	case UnixGramInput:
+		return newUnixGram(config, pluginConfig)
 	case HTTPInput:
 		return newHTTP(config, pluginConfig)
+	case FileInput:
+		return newFile(config, pluginConfig)
+	case SyslogInput:
+		return newSyslog(config, pluginConfig)
 	}
 	return nil, errors.New("specified input plugin does not exist")
 }
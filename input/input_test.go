@@ -7,10 +7,14 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"net"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -179,11 +183,30 @@ func TestHttp(t *testing.T) {
 		t.Fatal("Something is wrong http plugin improperlly parsed event.")
 	}
 
+	resp, err = http.Post("http://localhost:9093", "application/json", bytes.NewBuffer([]byte(`[{"message": "first"}, {"message": "second"}]`)))
+	if err != nil || resp == nil || resp.StatusCode != 200 {
+		t.Fatal("Something is wrong sent batch data wasn't handled properly.")
+	}
+
+	test, err = h.Next()
+	if err != nil || test == nil {
+		t.Fatal("Something is wrong couldn't retrieve sent batch data.")
+	}
+
+	if batch, ok := test.Data["message"].([]interface{}); !ok || len(batch) != 2 {
+		t.Fatal("Something is wrong http plugin improperlly parsed batch event.")
+	}
+
 	resp, err = http.Post("http://localhost:9093", "text/plain", bytes.NewBuffer([]byte("testing string handling")))
 	if err != nil || resp == nil || resp.StatusCode == 200 {
 		t.Fatal("Something is wrong sent data wasn't handled properly.")
 	}
 
+	resp, err = http.Post("http://localhost:9093", "application/json", bytes.NewBuffer([]byte("")))
+	if err != nil || resp == nil || resp.StatusCode == 200 {
+		t.Fatal("Something is wrong an empty body wasn't rejected.")
+	}
+
 	name := h.Name()
 	if name != "Testing Http" {
 		t.Fatal("Something is wrong name wasn't handled properly.")
@@ -194,3 +217,701 @@ func TestHttp(t *testing.T) {
 		t.Fatal("Something is wrong close wasn't handled properly.")
 	}
 }
+
+func fileNext(t *testing.T, in Input) *common.Event {
+	events := make(chan *common.Event, 1)
+	go func() {
+		event, _ := in.Next()
+		events <- event
+	}()
+
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("file input did not return an event in time.")
+	}
+	return nil
+}
+
+func TestFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "protond-file-input")
+	if err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+	defer os.RemoveAll(dir)
+
+	config := &common.Config{Backlog: 1024, DataDir: dir, Log: common.NewLogger(common.NoopLogger)}
+
+	file, err := New(FileInput, config, &common.PluginConfig{Name: "Testing File", Type: "file", Config: map[string]string{}})
+	if err == nil || file != nil {
+		t.Fatal("file plugin did not throw an error when configured without a path definition.")
+	}
+
+	file, err = New(FileInput, config, &common.PluginConfig{Name: "Testing File", Type: "file", Config: map[string]string{"path": dir + "/*.log", "start_position": "middle"}})
+	if err == nil || file != nil {
+		t.Fatal("file plugin did not throw an error when configured with an invalid start_position.")
+	}
+
+	file, err = New(FileInput, config, &common.PluginConfig{Name: "Testing File", Type: "file", Config: map[string]string{"path": dir + "/*.log", "poll_interval": "never"}})
+	if err == nil || file != nil {
+		t.Fatal("file plugin did not throw an error when configured with an invalid poll_interval.")
+	}
+
+	logPath := dir + "/test.log"
+	if err := ioutil.WriteFile(logPath, []byte("first\nsecond\n"), 0644); err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+
+	pluginConfig := &common.PluginConfig{
+		Name: "Testing File",
+		Type: "file",
+		Config: map[string]string{
+			"path":                dir + "/*.log*",
+			"start_position":      "beginning",
+			"poll_interval":       "10ms",
+			"discover_interval":   "50ms",
+			"checkpoint_interval": "50ms",
+		},
+	}
+
+	file, err = New(FileInput, config, pluginConfig)
+	if err != nil {
+		t.Fatalf("file plugin threw an error for no reason: %s", err.Error())
+	}
+	if file.Name() != "Testing File" {
+		t.Fatal("Something is wrong name wasn't handled properly.")
+	}
+	if err := file.Open(); err != nil {
+		t.Fatalf("file plugin failed to open: %s", err.Error())
+	}
+
+	for _, expected := range []string{"first", "second"} {
+		event := fileNext(t, file)
+		if event.Data["message"] != expected || event.Data["path"] != logPath {
+			t.Fatalf("file plugin improperly read line, expected '%s' got '%v'.", expected, event.Data["message"])
+		}
+	}
+
+	// Lines written in pieces are only returned once complete.
+	f, _ := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString("thi")
+	time.Sleep(50 * time.Millisecond)
+	f.WriteString("rd\n")
+	f.Close()
+
+	if event := fileNext(t, file); event.Data["message"] != "third" {
+		t.Fatalf("file plugin improperly read appended line, got '%v'.", event.Data["message"])
+	}
+
+	// Rotation by rename, the rest of the rotated file is read and the new file is read from the beginning.
+	f, _ = os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString("fourth\n")
+	f.Close()
+	if err := os.Rename(logPath, logPath+".1"); err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+	ioutil.WriteFile(logPath, []byte("fifth\n"), 0644)
+
+	seen := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		seen[fileNext(t, file).Data["message"].(string)] = true
+	}
+	if !seen["fourth"] || !seen["fifth"] {
+		t.Fatalf("file plugin did not follow the rotated file properly: %v", seen)
+	}
+
+	// Truncation, the file is read again from the beginning.
+	time.Sleep(50 * time.Millisecond)
+	ioutil.WriteFile(logPath, []byte("six\n"), 0644)
+	if event := fileNext(t, file); event.Data["message"] != "six" {
+		t.Fatalf("file plugin did not handle truncation properly, got '%v'.", event.Data["message"])
+	}
+
+	if err := file.Close(); err != nil {
+		t.Fatalf("file plugin failed to close: %s", err.Error())
+	}
+	if err := file.Close(); err != nil {
+		t.Fatal("Something is wrong close wasn't idempotent.")
+	}
+
+	// Restarting resumes from the persisted offsets, without repeating any lines.
+	f, _ = os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString("seventh\n")
+	f.Close()
+
+	file, err = New(FileInput, config, pluginConfig)
+	if err != nil {
+		t.Fatalf("file plugin threw an error for no reason: %s", err.Error())
+	}
+	if err := file.Open(); err != nil {
+		t.Fatalf("file plugin failed to open: %s", err.Error())
+	}
+	defer file.Close()
+
+	if event := fileNext(t, file); event.Data["message"] != "seventh" {
+		t.Fatalf("file plugin did not resume from its persisted offset, got '%v'.", event.Data["message"])
+	}
+}
+
+func TestParseSyslog(t *testing.T) {
+	now := time.Date(2017, time.March, 10, 12, 0, 0, 0, time.UTC)
+
+	timestamp, data, err := parseSyslog(`<165>1 2017-03-01T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut="3" eventSource="Appl\"ication" eventID="1011"][examplePriority@32473 class="high"] `+"\xef\xbb\xbf"+"An application event log entry...\n", now, time.UTC)
+	if err != nil {
+		t.Fatalf("failed to parse a valid rfc 5424 message: %s", err.Error())
+	}
+	if !timestamp.Equal(time.Date(2017, time.March, 1, 22, 14, 15, 3000000, time.UTC)) {
+		t.Fatalf("rfc 5424 timestamp improperly parsed: %s", timestamp)
+	}
+	if data["priority"] != 165 || data["facility"] != 20 || data["severity"] != 5 || data["version"] != 1 {
+		t.Fatalf("rfc 5424 priority improperly parsed: %v", data)
+	}
+	if data["hostname"] != "mymachine.example.com" || data["app_name"] != "evntslog" || data["msgid"] != "ID47" || data["message"] != "An application event log entry..." {
+		t.Fatalf("rfc 5424 header improperly parsed: %v", data)
+	}
+	if _, ok := data["procid"]; ok {
+		t.Fatal("rfc 5424 nil procid should not be set.")
+	}
+
+	structured := data["structured_data"].(map[string]interface{})
+	example := structured["exampleSDID@32473"].(map[string]interface{})
+	if len(structured) != 2 || example["iut"] != "3" || example["eventSource"] != `Appl"ication` || structured["examplePriority@32473"].(map[string]interface{})["class"] != "high" {
+		t.Fatalf("rfc 5424 structured data improperly parsed: %v", structured)
+	}
+
+	timestamp, data, err = parseSyslog("<34>1 - - su 1234 - -", now, time.UTC)
+	if err != nil || !timestamp.Equal(now) || data["app_name"] != "su" || data["procid"] != "1234" || data["message"] != "" {
+		t.Fatalf("rfc 5424 message without a timestamp or body improperly parsed: %v %v", data, err)
+	}
+
+	timestamp, data, err = parseSyslog("<34>Oct 11 22:14:15 mymachine su[230]: 'su root' failed for lonvick on /dev/pts/8", now, time.UTC)
+	if err != nil {
+		t.Fatalf("failed to parse a valid rfc 3164 message: %s", err.Error())
+	}
+	if !timestamp.Equal(time.Date(2016, time.October, 11, 22, 14, 15, 0, time.UTC)) {
+		t.Fatalf("rfc 3164 timestamp improperly parsed: %s", timestamp)
+	}
+	if data["facility"] != 4 || data["severity"] != 2 || data["hostname"] != "mymachine" || data["app_name"] != "su" || data["procid"] != "230" || data["message"] != "'su root' failed for lonvick on /dev/pts/8" {
+		t.Fatalf("rfc 3164 message improperly parsed: %v", data)
+	}
+
+	timestamp, data, err = parseSyslog("<13>Mar  9 08:00:00 host kernel: oops", now, time.UTC)
+	if err != nil || !timestamp.Equal(time.Date(2017, time.March, 9, 8, 0, 0, 0, time.UTC)) || data["app_name"] != "kernel" || data["message"] != "oops" {
+		t.Fatalf("rfc 3164 message improperly parsed: %v %v", data, err)
+	}
+
+	timestamp, data, err = parseSyslog("<13>just some text", now, time.UTC)
+	if err != nil || !timestamp.Equal(now) || data["message"] != "just some text" {
+		t.Fatalf("rfc 3164 message without a header improperly parsed: %v %v", data, err)
+	}
+
+	for _, raw := range []string{"no priority", "<>1 - - - - - -", "<192>test", "<1>1 2017-03-01T22:14:15Z host", "<1>1 nope host app - - -", "<1>1 - host app - - [broken", "<1>1 - host app - - [id a=b]"} {
+		if _, _, err := parseSyslog(raw, now, time.UTC); err == nil {
+			t.Fatalf("invalid syslog message was parsed without error: %s", raw)
+		}
+	}
+}
+
+func TestSyslog(t *testing.T) {
+	config := &common.Config{Backlog: 1024, Log: common.NewLogger(common.NoopLogger)}
+
+	syslog, err := New(SyslogInput, config, &common.PluginConfig{Name: "Testing Syslog", Type: "syslog", Config: map[string]string{"host": "localhost"}})
+	if err == nil || syslog != nil {
+		t.Fatal("syslog plugin did not throw an error when configured without a port definition.")
+	}
+
+	syslog, err = New(SyslogInput, config, &common.PluginConfig{Name: "Testing Syslog", Type: "syslog", Config: map[string]string{"host": "localhost", "port": "9095", "protocol": "sctp"}})
+	if err == nil || syslog != nil {
+		t.Fatal("syslog plugin did not throw an error when configured with an invalid protocol.")
+	}
+
+	syslog, err = New(SyslogInput, config, &common.PluginConfig{Name: "Testing Syslog", Type: "syslog", Config: map[string]string{"host": "localhost", "port": "9095", "timezone": "Nowhere/Special"}})
+	if err == nil || syslog != nil {
+		t.Fatal("syslog plugin did not throw an error when configured with an invalid timezone.")
+	}
+
+	syslog, err = New(SyslogInput, config, &common.PluginConfig{Name: "Testing Syslog", Type: "syslog", Config: map[string]string{"host": "127.0.0.1", "port": "9095"}})
+	if err != nil {
+		t.Fatalf("syslog plugin threw an error for no reason: %s", err.Error())
+	}
+	if syslog.Name() != "Testing Syslog" {
+		t.Fatal("Something is wrong name wasn't handled properly.")
+	}
+	if err := syslog.Open(); err != nil {
+		t.Fatalf("syslog plugin failed to open: %s", err.Error())
+	}
+	defer syslog.Close()
+
+	udp, err := net.Dial("udp", "127.0.0.1:9095")
+	if err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+	udp.Write([]byte("<14>1 2017-03-01T22:14:15Z host app 1 - - over udp"))
+	udp.Close()
+
+	event, err := syslog.Next()
+	if err != nil || event.Data["message"] != "over udp" || event.Data["source"] != "127.0.0.1" || !event.Timestamp.Equal(time.Date(2017, time.March, 1, 22, 14, 15, 0, time.UTC)) {
+		t.Fatalf("syslog plugin improperly handled a udp message: %v", event.Data)
+	}
+
+	tcp, err := net.Dial("tcp", "127.0.0.1:9095")
+	if err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+	framed := "<14>1 - host app - - - octet\ncounted"
+	tcp.Write([]byte("<14>Mar  1 22:14:15 host app: newline\n" + strconv.Itoa(len(framed)) + " " + framed + "garbage\n"))
+	tcp.Close()
+
+	for _, expected := range []string{"newline", "octet\ncounted", "garbage"} {
+		event, err = syslog.Next()
+		if err != nil || event.Data["message"] != expected {
+			t.Fatalf("syslog plugin improperly handled a tcp message, expected '%s' got: %v", expected, event.Data)
+		}
+	}
+	if !event.HasTag(SyslogFailureTag) {
+		t.Fatal("syslog plugin did not tag a message that failed to parse.")
+	}
+}
+
+func TestUDP(t *testing.T) {
+	config := &common.Config{Backlog: 1024, Log: common.NewLogger(common.NoopLogger)}
+
+	invalid := []map[string]string{
+		{"host": "127.0.0.1"},
+		{"host": "127.0.0.1", "port": "9096", "buffer_size": "-1"},
+		{"host": "127.0.0.1", "port": "9096", "read_buffer": "lots"},
+		{"host": "127.0.0.1", "port": "9096", "multicast_group": "10.0.0.1"},
+		{"host": "127.0.0.1", "port": "9096", "multicast_group": "239.0.0.1", "interface": "doesnotexist0"},
+	}
+	for _, pluginConfig := range invalid {
+		udp, err := New(UDPInput, config, &common.PluginConfig{Name: "Testing UDP", Type: "udp", Config: pluginConfig})
+		if err == nil || udp != nil {
+			t.Fatalf("udp plugin did not throw an error for an invalid configuration: %v", pluginConfig)
+		}
+	}
+
+	udp, err := New(UDPInput, config, &common.PluginConfig{Name: "Testing UDP", Type: "udp", Config: map[string]string{"host": "127.0.0.1", "port": "9096", "buffer_size": "8", "read_buffer": "65536"}})
+	if err != nil {
+		t.Fatalf("udp plugin threw an error for no reason: %s", err.Error())
+	}
+	if udp.Name() != "Testing UDP" {
+		t.Fatal("Something is wrong name wasn't handled properly.")
+	}
+	if err := udp.Open(); err != nil {
+		t.Fatalf("udp plugin failed to open: %s", err.Error())
+	}
+
+	conn, err := net.Dial("udp", "127.0.0.1:9096")
+	if err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+	conn.Write([]byte("test\n"))
+	conn.Write([]byte("truncated datagram"))
+	conn.Close()
+
+	for _, expected := range []string{"test", "truncate"} {
+		event, err := udp.Next()
+		if err != nil || event.Data["message"] != expected || event.Data["source"] != "127.0.0.1" {
+			t.Fatalf("udp plugin improperly handled a datagram, expected '%s' got: %v", expected, event.Data)
+		}
+	}
+
+	if err := udp.Close(); err != nil {
+		t.Fatal("Something is wrong close wasn't handled properly.")
+	}
+
+	udp, err = New(UDPInput, config, &common.PluginConfig{Name: "Testing UDP", Type: "udp", Config: map[string]string{"host": "127.0.0.1", "port": "9096", "json": "true"}})
+	if err != nil {
+		t.Fatalf("udp plugin threw an error for no reason: %s", err.Error())
+	}
+	if err := udp.Open(); err != nil {
+		t.Fatalf("udp plugin failed to open: %s", err.Error())
+	}
+	defer udp.Close()
+
+	conn, err = net.Dial("udp", "127.0.0.1:9096")
+	if err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+	conn.Write([]byte(`{"host":"device","value":42}`))
+	conn.Write([]byte(`[1,2]`))
+	conn.Write([]byte(`not json`))
+	conn.Close()
+
+	event, _ := udp.Next()
+	if event.Data["host"] != "device" || event.Data["value"] != float64(42) || event.Data["source"] != "127.0.0.1" || len(event.Tags) != 0 {
+		t.Fatalf("udp plugin improperly decoded a json datagram: %v", event.Data)
+	}
+
+	event, _ = udp.Next()
+	if batch, ok := event.Data["message"].([]interface{}); !ok || len(batch) != 2 {
+		t.Fatalf("udp plugin improperly wrapped a json array: %v", event.Data)
+	}
+
+	event, _ = udp.Next()
+	if event.Data["message"] != "not json" || !event.HasTag(UDPFailureTag) {
+		t.Fatalf("udp plugin improperly handled an invalid json datagram: %v", event.Data)
+	}
+}
+
+func TestUDPMulticast(t *testing.T) {
+	config := &common.Config{Backlog: 1024, Log: common.NewLogger(common.NoopLogger)}
+
+	udp, err := New(UDPInput, config, &common.PluginConfig{Name: "Testing UDP", Type: "udp", Config: map[string]string{"port": "9097", "multicast_group": "239.255.0.1", "interface": "lo"}})
+	if err != nil {
+		t.Skipf("loopback interface is unavailable: %s", err.Error())
+	}
+	if err := udp.Open(); err != nil {
+		t.Fatalf("udp plugin failed to join the multicast group: %s", err.Error())
+	}
+	defer udp.Close()
+
+	// Binding the sender to the loopback address sends the datagram out of the loopback interface.
+	conn, err := net.DialUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}, &net.UDPAddr{IP: net.IPv4(239, 255, 0, 1), Port: 9097})
+	if err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+	conn.Write([]byte("multicast"))
+	conn.Close()
+
+	events := make(chan *common.Event, 1)
+	go func() {
+		event, _ := udp.Next()
+		events <- event
+	}()
+
+	select {
+	case event := <-events:
+		if event.Data["message"] != "multicast" {
+			t.Fatalf("udp plugin improperly handled a multicast datagram: %v", event.Data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("udp plugin did not receive the multicast datagram.")
+	}
+}
+
+func TestUnix(t *testing.T) {
+	dir, err := ioutil.TempDir("", "protond-unix-input")
+	if err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+	defer os.RemoveAll(dir)
+
+	config := &common.Config{Backlog: 1024, Log: common.NewLogger(common.NoopLogger)}
+	socketPath := dir + "/run/ingest.sock"
+
+	for _, plugin := range []string{UnixInput, UnixGramInput} {
+		in, err := New(plugin, config, &common.PluginConfig{Name: "Testing Unix", Type: plugin, Config: map[string]string{}})
+		if err == nil || in != nil {
+			t.Fatalf("%s plugin did not throw an error when configured without a path definition.", plugin)
+		}
+
+		in, err = New(plugin, config, &common.PluginConfig{Name: "Testing Unix", Type: plugin, Config: map[string]string{"path": socketPath, "mode": "999"}})
+		if err == nil || in != nil {
+			t.Fatalf("%s plugin did not throw an error when configured with an invalid mode.", plugin)
+		}
+
+		// Leave a stale socket behind, as a crashed process would.
+		os.MkdirAll(dir+"/run", 0755)
+		if plugin == UnixInput {
+			stale, err := net.ListenUnix(plugin, &net.UnixAddr{Name: socketPath, Net: plugin})
+			if err != nil {
+				t.Fatalf("failed to create a stale socket: %s", err.Error())
+			}
+			stale.SetUnlinkOnClose(false)
+			stale.Close()
+		} else {
+			stale, err := net.ListenUnixgram(plugin, &net.UnixAddr{Name: socketPath, Net: plugin})
+			if err != nil {
+				t.Fatalf("failed to create a stale socket: %s", err.Error())
+			}
+			stale.Close()
+		}
+
+		in, err = New(plugin, config, &common.PluginConfig{Name: "Testing Unix", Type: plugin, Config: map[string]string{"path": socketPath, "mode": "0600"}})
+		if err != nil {
+			t.Fatalf("%s plugin threw an error for no reason: %s", plugin, err.Error())
+		}
+		if in.Name() != "Testing Unix" {
+			t.Fatal("Something is wrong name wasn't handled properly.")
+		}
+		if err := in.Open(); err != nil {
+			t.Fatalf("%s plugin failed to open over a stale socket: %s", plugin, err.Error())
+		}
+
+		info, err := os.Stat(socketPath)
+		if err != nil || info.Mode().Perm() != 0600 {
+			t.Fatalf("%s plugin did not set the permissions of the socket.", plugin)
+		}
+
+		second, _ := New(plugin, config, &common.PluginConfig{Name: "Testing Unix", Type: plugin, Config: map[string]string{"path": socketPath}})
+		if err := second.Open(); err == nil {
+			t.Fatalf("%s plugin replaced a socket that is in use.", plugin)
+		}
+
+		conn, err := net.Dial(plugin, socketPath)
+		if err != nil {
+			t.Fatalf("failed to connect to the %s plugin: %s", plugin, err.Error())
+		}
+		if plugin == UnixInput {
+			conn.Write([]byte("first\nsecond\n"))
+		} else {
+			conn.Write([]byte("first\n"))
+			conn.Write([]byte("second"))
+		}
+		conn.Close()
+
+		for _, expected := range []string{"first", "second"} {
+			event, err := in.Next()
+			if err != nil || event.Data["message"] != expected {
+				t.Fatalf("%s plugin improperly handled a message, expected '%s' got: %v", plugin, expected, event.Data)
+			}
+		}
+
+		if err := in.Close(); err != nil {
+			t.Fatalf("%s plugin failed to close: %s", plugin, err.Error())
+		}
+		if _, err := os.Stat(socketPath); !os.IsNotExist(err) {
+			t.Fatalf("%s plugin did not remove its socket when closed.", plugin)
+		}
+	}
+
+	if err := ioutil.WriteFile(socketPath, []byte("not a socket"), 0644); err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+	in, _ := New(UnixInput, config, &common.PluginConfig{Name: "Testing Unix", Type: UnixInput, Config: map[string]string{"path": socketPath}})
+	if err := in.Open(); err == nil {
+		t.Fatal("unix plugin replaced a file that is not a socket.")
+	}
+}
+
+func TestCodec(t *testing.T) {
+	config := &common.Config{Backlog: 1024, Log: common.NewLogger(common.NoopLogger)}
+
+	for _, plugin := range []string{StdinInput, TCPInput, UDPInput, UnixInput, UnixGramInput, FileInput, HTTPInput} {
+		in, err := New(plugin, config, &common.PluginConfig{Name: "Testing Codec", Type: plugin, Config: map[string]string{"host": "127.0.0.1", "port": "9098", "path": "/tmp/protond-codec", "codec": "doesn't exist"}})
+		if err == nil || in != nil {
+			t.Fatalf("%s plugin did not throw an error when configured with an invalid codec.", plugin)
+		}
+	}
+
+	tcp, err := New(TCPInput, config, &common.PluginConfig{Name: "Testing Codec", Type: "tcp", Config: map[string]string{"host": "127.0.0.1", "port": "9098", "codec": "json_lines"}})
+	if err != nil {
+		t.Fatalf("tcp plugin threw an error for no reason: %s", err.Error())
+	}
+	if err := tcp.Open(); err != nil {
+		t.Fatalf("tcp plugin failed to open: %s", err.Error())
+	}
+	defer tcp.Close()
+
+	conn, err := net.Dial("tcp", "127.0.0.1:9098")
+	if err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+	conn.Write([]byte("{\"status\": 200, \"path\": \"/\"}\nnot json\n"))
+	conn.Close()
+
+	event, err := tcp.Next()
+	if err != nil || event.Data["status"] != float64(200) || event.Data["path"] != "/" || event.Input != "Testing Codec" || len(event.Tags) != 0 {
+		t.Fatalf("tcp plugin did not decode a json line into the event data: %v", event.Data)
+	}
+
+	event, err = tcp.Next()
+	if err != nil || event.Data["message"] != "not json" || !event.HasTag("_jsonparsefailure") {
+		t.Fatalf("tcp plugin did not tag a line that failed to decode: %v", event.Data)
+	}
+
+	msgpack, err := New(TCPInput, config, &common.PluginConfig{Name: "Testing Codec", Type: "tcp", Config: map[string]string{"host": "127.0.0.1", "port": "9099", "codec": "msgpack"}})
+	if err != nil {
+		t.Fatalf("tcp plugin threw an error for no reason: %s", err.Error())
+	}
+	if err := msgpack.Open(); err != nil {
+		t.Fatalf("tcp plugin failed to open: %s", err.Error())
+	}
+	defer msgpack.Close()
+
+	conn, err = net.Dial("tcp", "127.0.0.1:9099")
+	if err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+	// Two maps, {"count": 10, "path": "/"} and {"count": 11, "path": "/"}, the value 10 is encoded as a new line byte.
+	conn.Write([]byte{0x82, 0xa5, 'c', 'o', 'u', 'n', 't', 0x0a, 0xa4, 'p', 'a', 't', 'h', 0xa1, '/', 0x82, 0xa5, 'c', 'o', 'u', 'n', 't', 0x0b, 0xa4, 'p', 'a', 't', 'h', 0xa1, '/'})
+	conn.Close()
+
+	for _, expected := range []int64{10, 11} {
+		event, err = msgpack.Next()
+		if err != nil || event.Data["count"] != expected || event.Data["path"] != "/" || len(event.Tags) != 0 {
+			t.Fatalf("tcp plugin split a msgpack value on a new line byte, expected %d got: %v", expected, event.Data)
+		}
+	}
+
+	if file, err := New(FileInput, config, &common.PluginConfig{Name: "Testing Codec", Type: "file", Config: map[string]string{"path": "/tmp/protond-codec", "codec": "msgpack"}}); err == nil || file != nil {
+		t.Fatal("file plugin did not throw an error when configured with a codec that can't be read line by line.")
+	}
+
+	unixgram, err := New(UnixGramInput, config, &common.PluginConfig{Name: "Testing Codec", Type: "unixgram", Config: map[string]string{"path": os.TempDir() + "/protond-codec.sock", "codec": "csv", "codec_columns": "level,message"}})
+	if err != nil {
+		t.Fatalf("unixgram plugin threw an error for no reason: %s", err.Error())
+	}
+	if err := unixgram.Open(); err != nil {
+		t.Fatalf("unixgram plugin failed to open: %s", err.Error())
+	}
+	defer unixgram.Close()
+
+	conn, err = net.Dial("unixgram", os.TempDir()+"/protond-codec.sock")
+	if err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+	conn.Write([]byte("info,first\nwarn,\"second, with a comma\"\n"))
+	conn.Close()
+
+	for _, expected := range []string{"first", "second, with a comma"} {
+		event, err = unixgram.Next()
+		if err != nil || event.Data["message"] != expected {
+			t.Fatalf("unixgram plugin did not decode every row of a datagram, expected '%s' got: %v", expected, event.Data)
+		}
+	}
+}
+
+type failingCodec struct {
+	tag string
+}
+
+func (c *failingCodec) Decode(frame []byte) ([]map[string]interface{}, error) {
+	return nil, errors.New("failed to decode")
+}
+
+func (c *failingCodec) Encode(event *common.Event) ([]byte, error) {
+	return nil, errors.New("failed to encode")
+}
+
+func (c *failingCodec) Name() string {
+	return "failing"
+}
+
+func (c *failingCodec) FailureTag() string {
+	return c.tag
+}
+
+func TestDecode(t *testing.T) {
+	events := decode(&failingCodec{tag: "_failure"}, "Testing Decode", []byte("woot\n"))
+	if len(events) != 1 || events[0].Data["message"] != "woot" || len(events[0].Tags) != 1 || !events[0].HasTag("_failure") {
+		t.Fatal("decode did not tag a frame that failed to decode with the failure tag of the codec.")
+	}
+
+	events = decode(&failingCodec{}, "Testing Decode", []byte("woot\n"))
+	if len(events) != 1 || events[0].Data["message"] != "woot" || len(events[0].Tags) != 0 {
+		t.Fatal("decode tagged a frame that failed to decode with a codec that has no failure tag.")
+	}
+}
+
+type splittingCodec struct {
+	failingCodec
+}
+
+func (c *splittingCodec) Decode(frame []byte) ([]map[string]interface{}, error) {
+	message := strings.TrimRight(string(frame), "\n")
+	return []map[string]interface{}{{"message": message}, {"message": message}}, nil
+}
+
+func TestFilePartialLineOffsets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "protond-file-input")
+	if err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+	defer os.RemoveAll(dir)
+
+	logPath := dir + "/test.log"
+	if err := ioutil.WriteFile(logPath, []byte("x\n"), 0644); err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+
+	config := &common.Config{Backlog: 1024, DataDir: dir, Log: common.NewLogger(common.NoopLogger)}
+	in, err := New(FileInput, config, &common.PluginConfig{Name: "Testing File", Type: "file", Config: map[string]string{"path": logPath, "start_position": "beginning", "poll_interval": "10ms"}})
+	if err != nil {
+		t.Fatalf("file plugin threw an error for no reason: %s", err.Error())
+	}
+
+	file := in.(*File)
+	file.codec = &splittingCodec{}
+	if err := file.Open(); err != nil {
+		t.Fatalf("file plugin failed to open: %s", err.Error())
+	}
+	defer file.Close()
+
+	fileNext(t, file)
+	fileNext(t, file)
+
+	f, _ := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString("ab")
+	time.Sleep(50 * time.Millisecond)
+	f.WriteString("cd\n")
+	f.Close()
+
+	// Every event but the last from a line commits the start of the line, even when the line was read in pieces.
+	for _, expected := range []int64{2, 7} {
+		if event := fileNext(t, file); event.Data["message"] != "abcd" {
+			t.Fatalf("file plugin improperly read the appended line, got '%v'.", event.Data["message"])
+		}
+
+		file.lock.Lock()
+		var committed int64
+		for _, tail := range file.tails {
+			committed = atomic.LoadInt64(&tail.committed)
+		}
+		file.lock.Unlock()
+
+		if committed != expected {
+			t.Fatalf("file plugin committed the offset %d, expected %d.", committed, expected)
+		}
+	}
+}
+
+func TestFileReusedIdentity(t *testing.T) {
+	dir, err := ioutil.TempDir("", "protond-file-input")
+	if err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+	defer os.RemoveAll(dir)
+
+	// One file was rotated to a new path and kept growing, the other is a new file that reused the identity of a deleted one.
+	ioutil.WriteFile(dir+"/rotated.log", []byte("old\nrotated\n"), 0644)
+	ioutil.WriteFile(dir+"/reused.log", []byte("reused\n"), 0644)
+
+	checkpoints := make(map[string]*fileCheckpoint)
+	for name, checkpoint := range map[string]*fileCheckpoint{
+		"rotated.log": {Path: dir + "/current.log", Offset: 4, Size: 4},
+		"reused.log":  {Path: dir + "/deleted.log", Offset: 4, Size: 100},
+	} {
+		info, err := os.Stat(dir + "/" + name)
+		if err != nil {
+			t.Fatal("Something is very very wrong.")
+		}
+		checkpoints[fileIdentity(info)] = checkpoint
+	}
+
+	buf, _ := json.Marshal(checkpoints)
+	os.MkdirAll(dir+"/inputs", 0755)
+	if err := ioutil.WriteFile(dir+"/inputs/Testing File.offsets", buf, 0644); err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+
+	config := &common.Config{Backlog: 1024, DataDir: dir, Log: common.NewLogger(common.NoopLogger)}
+	file, err := New(FileInput, config, &common.PluginConfig{Name: "Testing File", Type: "file", Config: map[string]string{"path": dir + "/*.log", "start_position": "beginning", "poll_interval": "10ms"}})
+	if err != nil {
+		t.Fatalf("file plugin threw an error for no reason: %s", err.Error())
+	}
+	if err := file.Open(); err != nil {
+		t.Fatalf("file plugin failed to open: %s", err.Error())
+	}
+	defer file.Close()
+
+	seen := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		seen[fileNext(t, file).Data["message"].(string)] = true
+	}
+	if !seen["rotated"] || !seen["reused"] {
+		t.Fatalf("file plugin did not tell a rotated file apart from a new file reusing the identity of a deleted one: %v", seen)
+	}
+}
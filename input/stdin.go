@@ -6,33 +6,34 @@ package input
 import (
 	"bufio"
 	"os"
-	"time"
 
+	"github.com/Supernomad/protond/codec"
 	"github.com/Supernomad/protond/common"
 )
 
 // Stdin is a struct representing the standard input plugin.
 type Stdin struct {
-	config *common.Config
-	name   string
-	reader *bufio.Reader
+	config  *common.Config
+	name    string
+	reader  *bufio.Reader
+	codec   codec.Codec
+	pending []*common.Event
 }
 
-// Next will return the next event from standard input.
+// Next will return the next event from standard input, frames that decode into several events are returned over successive calls.
 func (stdin *Stdin) Next() (*common.Event, error) {
-	text, err := stdin.reader.ReadString('\n')
-	if err != nil {
-		return nil, err
-	}
+	for len(stdin.pending) == 0 {
+		frame, err := codec.ReadFrame(stdin.codec, stdin.reader)
+		if err != nil {
+			return nil, err
+		}
 
-	event := &common.Event{
-		Timestamp: time.Now(),
-		Input:     stdin.name,
-		Data: map[string]interface{}{
-			"message": text[:len(text)-1],
-		},
+		stdin.pending = decode(stdin.codec, stdin.name, frame)
 	}
 
+	event := stdin.pending[0]
+	stdin.pending = stdin.pending[1:]
+
 	return event, nil
 }
 
@@ -51,12 +52,17 @@ func (stdin *Stdin) Close() error {
 	return nil
 }
 
-func newStdin(config *common.Config) (Input, error) {
+func newStdin(config *common.Config, pluginConfig *common.PluginConfig) (Input, error) {
 	stdin := &Stdin{
 		config: config,
 		name:   "Stdin",
 	}
 
+	var err error
+	if stdin.codec, err = codec.Configured(codec.PlainCodec, config, pluginConfig); err != nil {
+		return nil, err
+	}
+
 	if tmpFile := os.Getenv("_TESTING_PROTOND"); tmpFile != "" {
 		file, _ := os.Open(tmpFile)
 
@@ -0,0 +1,198 @@
+// Copyright (c) 2017 Christian Saide <Supernomad>
+// Licensed under the MPL-2.0, for details see https://github.com/Supernomad/protond/blob/master/LICENSE
+
+package input
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/Supernomad/protond/common"
+)
+
+const (
+	// SyslogFailureTag is the tag added to events received by the syslog input plugin that could not be parsed, the raw message is kept in the 'message' field.
+	SyslogFailureTag = "_syslogparsefailure"
+
+	syslogUDP  = "udp"
+	syslogTCP  = "tcp"
+	syslogBoth = "both"
+)
+
+type syslogMessage struct {
+	text   string
+	source string
+}
+
+// Syslog is a struct representing the syslog input plugin.
+type Syslog struct {
+	config       *common.Config
+	pluginConfig *common.PluginConfig
+	messages     chan *syslogMessage
+	protocol     string
+	location     *time.Location
+	listener     *net.TCPListener
+	conn         *net.UDPConn
+}
+
+func (syslog *Syslog) accept(listener *net.TCPListener) {
+	for {
+		conn, err := listener.AcceptTCP()
+		if err != nil {
+			syslog.config.Log.Error.Println("[SYSLOG]", "Error accepting new connections with the syslog plugin.")
+			break
+		}
+
+		syslog.config.Log.Debug.Println("[SYSLOG]", "New syslog connection received.")
+		go syslog.handleConn(conn)
+	}
+}
+
+func (syslog *Syslog) handleConn(conn *net.TCPConn) {
+	defer conn.Close()
+
+	source := conn.RemoteAddr().(*net.TCPAddr).IP.String()
+	reader := bufio.NewReader(conn)
+	for {
+		text, err := readSyslogFrame(reader)
+		if err != nil {
+			syslog.config.Log.Debug.Println("[SYSLOG]", "Error reading from connection with the syslog plugin, considering connection dead and moving on.")
+			break
+		}
+
+		syslog.messages <- &syslogMessage{text: text, source: source}
+	}
+}
+
+func (syslog *Syslog) read(conn *net.UDPConn) {
+	buf := make([]byte, syslogMaxMessageLen)
+	for {
+		n, addr, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			syslog.config.Log.Debug.Println("[SYSLOG]", "Error reading from the udp socket with the syslog plugin, considering it closed.")
+			break
+		}
+
+		syslog.messages <- &syslogMessage{text: string(buf[:n]), source: addr.IP.String()}
+	}
+}
+
+// Next will return the next event from the internal event buffer, messages that fail to parse are returned with their raw text in the 'message' field and tagged with the SyslogFailureTag.
+func (syslog *Syslog) Next() (*common.Event, error) {
+	message := <-syslog.messages
+
+	event := &common.Event{
+		Timestamp: time.Now(),
+		Input:     syslog.pluginConfig.Name,
+	}
+
+	timestamp, data, err := parseSyslog(message.text, event.Timestamp, syslog.location)
+	if err != nil {
+		syslog.config.Log.Debug.Printf("[SYSLOG] Failed to parse a syslog message from %s: %s", message.source, err.Error())
+		data = map[string]interface{}{
+			"message": strings.TrimRight(message.text, "\r\n\x00"),
+		}
+		event.Tags = []string{SyslogFailureTag}
+	} else {
+		event.Timestamp = timestamp
+	}
+
+	data["source"] = message.source
+	event.Data = data
+
+	return event, nil
+}
+
+// Name returns the name of the syslog input plugin.
+func (syslog *Syslog) Name() string {
+	return syslog.pluginConfig.Name
+}
+
+// Open will start listening for syslog messages on the configured protocols.
+func (syslog *Syslog) Open() error {
+	address := syslog.pluginConfig.Config["host"] + ":" + syslog.pluginConfig.Config["port"]
+
+	if syslog.protocol != syslogUDP {
+		addr, err := net.ResolveTCPAddr("tcp", address)
+		if err != nil {
+			return err
+		}
+
+		if syslog.listener, err = net.ListenTCP("tcp", addr); err != nil {
+			return err
+		}
+
+		syslog.config.Log.Debug.Printf("[SYSLOG] New tcp listener created on %s.", address)
+		go syslog.accept(syslog.listener)
+	}
+
+	if syslog.protocol != syslogTCP {
+		addr, err := net.ResolveUDPAddr("udp", address)
+		if err != nil {
+			syslog.Close()
+			return err
+		}
+
+		if syslog.conn, err = net.ListenUDP("udp", addr); err != nil {
+			syslog.Close()
+			return err
+		}
+
+		syslog.config.Log.Debug.Printf("[SYSLOG] New udp listener created on %s.", address)
+		go syslog.read(syslog.conn)
+	}
+
+	return nil
+}
+
+// Close will stop listening for syslog messages.
+func (syslog *Syslog) Close() error {
+	var err error
+	if syslog.listener != nil {
+		err = syslog.listener.Close()
+		syslog.listener = nil
+	}
+
+	if syslog.conn != nil {
+		if closeErr := syslog.conn.Close(); err == nil {
+			err = closeErr
+		}
+		syslog.conn = nil
+	}
+
+	return err
+}
+
+func newSyslog(config *common.Config, pluginConfig *common.PluginConfig) (Input, error) {
+	syslog := &Syslog{
+		config:       config,
+		pluginConfig: pluginConfig,
+		messages:     make(chan *syslogMessage, config.Backlog),
+		protocol:     syslogBoth,
+		location:     time.UTC,
+	}
+
+	if pluginConfig.Config["port"] == "" {
+		return nil, errors.New("configuration for the syslog input plugin, '" + pluginConfig.Name + "', is missing a port definition")
+	}
+
+	if raw := pluginConfig.Config["protocol"]; raw != "" {
+		if raw != syslogUDP && raw != syslogTCP && raw != syslogBoth {
+			return nil, errors.New("configuration for the syslog input plugin, '" + pluginConfig.Name + "', has an invalid protocol, expected one of 'udp', 'tcp', or 'both'")
+		}
+		syslog.protocol = raw
+	}
+
+	if raw := pluginConfig.Config["timezone"]; raw != "" {
+		location, err := time.LoadLocation(raw)
+		if err != nil {
+			return nil, errors.New("configuration for the syslog input plugin, '" + pluginConfig.Name + "', has an invalid timezone: " + err.Error())
+		}
+		syslog.location = location
+	}
+
+	return syslog, nil
+}
@@ -0,0 +1,240 @@
+// Copyright (c) 2017 Christian Saide <Supernomad>
+// Licensed under the MPL-2.0, for details see https://github.com/Supernomad/protond/blob/master/LICENSE
+
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	syslogNil           = "-"
+	syslogBOM           = "\xef\xbb\xbf"
+	syslogStampLayout   = "Jan _2 15:04:05"
+	syslogMaxMessageLen = 64 * 1024
+)
+
+var (
+	errSyslogPriority       = errors.New("syslog message is missing a valid priority")
+	errSyslogHeader         = errors.New("syslog message has an incomplete header")
+	errSyslogStructuredData = errors.New("syslog message has invalid structured data")
+	errSyslogFrame          = errors.New("syslog stream has an invalid octet count")
+)
+
+// readSyslogFrame reads a single message from a syslog stream, which is either octet counted as described by RFC 6587 or terminated by a new line, as messages always begin with a priority a leading digit marks an octet counted frame.
+func readSyslogFrame(reader *bufio.Reader) (string, error) {
+	first, err := reader.Peek(1)
+	if err != nil {
+		return "", err
+	}
+
+	if first[0] < '1' || first[0] > '9' {
+		return reader.ReadString('\n')
+	}
+
+	prefix, err := reader.ReadString(' ')
+	if err != nil {
+		return "", err
+	}
+
+	length, err := strconv.Atoi(prefix[:len(prefix)-1])
+	if err != nil || length > syslogMaxMessageLen {
+		return "", errSyslogFrame
+	}
+
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(reader, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// syslogField splits the supplied string at the first space.
+func syslogField(raw string) (string, string) {
+	if i := strings.IndexByte(raw, ' '); i >= 0 {
+		return raw[:i], raw[i+1:]
+	}
+	return raw, ""
+}
+
+func isSyslogVersion(field string) bool {
+	if len(field) == 0 || len(field) > 2 || field[0] == '0' {
+		return false
+	}
+	for i := 0; i < len(field); i++ {
+		if field[i] < '0' || field[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// parseSyslog parses the supplied RFC 5424 or RFC 3164 message, returning the timestamp of the message along with its fields.
+// RFC 3164 timestamps carry neither a year nor a timezone, so they are interpreted in the supplied location and assumed to be from the current year unless that would put them in the future.
+func parseSyslog(raw string, now time.Time, location *time.Location) (time.Time, map[string]interface{}, error) {
+	raw = strings.TrimRight(raw, "\r\n\x00")
+
+	end := strings.IndexByte(raw, '>')
+	if len(raw) < 3 || raw[0] != '<' || end < 2 || end > 4 {
+		return time.Time{}, nil, errSyslogPriority
+	}
+
+	priority, err := strconv.Atoi(raw[1:end])
+	if err != nil || priority < 0 || priority > 191 {
+		return time.Time{}, nil, errSyslogPriority
+	}
+
+	data := map[string]interface{}{
+		"priority": priority,
+		"facility": priority / 8,
+		"severity": priority % 8,
+	}
+
+	rest := raw[end+1:]
+	if version, remainder := syslogField(rest); isSyslogVersion(version) {
+		timestamp, err := parseSyslog5424(remainder, now, data)
+		if err != nil {
+			return time.Time{}, nil, err
+		}
+		data["version"], _ = strconv.Atoi(version)
+		return timestamp, data, nil
+	}
+
+	return parseSyslog3164(rest, now, location, data), data, nil
+}
+
+func parseSyslog5424(rest string, now time.Time, data map[string]interface{}) (time.Time, error) {
+	var fields [5]string
+	for i := range fields {
+		if rest == "" {
+			return time.Time{}, errSyslogHeader
+		}
+		fields[i], rest = syslogField(rest)
+	}
+
+	timestamp := now
+	if fields[0] != syslogNil {
+		parsed, err := time.Parse(time.RFC3339Nano, fields[0])
+		if err != nil {
+			return time.Time{}, errors.New("syslog message has an invalid timestamp: " + fields[0])
+		}
+		timestamp = parsed
+	}
+
+	for i, name := range []string{"hostname", "app_name", "procid", "msgid"} {
+		if fields[i+1] != syslogNil {
+			data[name] = fields[i+1]
+		}
+	}
+
+	if strings.HasPrefix(rest, syslogNil) {
+		rest = rest[len(syslogNil):]
+	} else {
+		structured, remainder, err := parseStructuredData(rest)
+		if err != nil {
+			return time.Time{}, err
+		}
+		data["structured_data"] = structured
+		rest = remainder
+	}
+
+	if rest != "" && rest[0] != ' ' {
+		return time.Time{}, errSyslogStructuredData
+	}
+	data["message"] = strings.TrimPrefix(strings.TrimPrefix(rest, " "), syslogBOM)
+
+	return timestamp, nil
+}
+
+// parseStructuredData parses the structured data elements at the start of the supplied string into a map of element ids to their parameters, returning the remainder of the string.
+func parseStructuredData(raw string) (map[string]interface{}, string, error) {
+	if raw == "" || raw[0] != '[' {
+		return nil, "", errSyslogStructuredData
+	}
+
+	structured := make(map[string]interface{})
+	for raw != "" && raw[0] == '[' {
+		i := 1
+		for i < len(raw) && raw[i] != ' ' && raw[i] != ']' {
+			i++
+		}
+		if i == 1 || i >= len(raw) {
+			return nil, "", errSyslogStructuredData
+		}
+
+		id := raw[1:i]
+		params := make(map[string]interface{})
+		for i < len(raw) && raw[i] == ' ' {
+			i++
+			eq := strings.IndexByte(raw[i:], '=')
+			if eq <= 0 || i+eq+1 >= len(raw) || raw[i+eq+1] != '"' {
+				return nil, "", errSyslogStructuredData
+			}
+
+			name := raw[i : i+eq]
+			i += eq + 2
+
+			var value bytes.Buffer
+			for ; i < len(raw) && raw[i] != '"'; i++ {
+				// Only '"', '\' and ']' are escaped, any other backslash is kept as is.
+				if raw[i] == '\\' && i+1 < len(raw) && strings.IndexByte("\"\\]", raw[i+1]) >= 0 {
+					i++
+				}
+				value.WriteByte(raw[i])
+			}
+			if i >= len(raw) {
+				return nil, "", errSyslogStructuredData
+			}
+
+			params[name] = value.String()
+			i++
+		}
+
+		if i >= len(raw) || raw[i] != ']' {
+			return nil, "", errSyslogStructuredData
+		}
+
+		structured[id] = params
+		raw = raw[i+1:]
+	}
+
+	return structured, raw, nil
+}
+
+// parseSyslog3164 leniently parses the supplied RFC 3164 message, as the format is loosely followed in practice a message without a timestamp keeps everything after the priority, aside from the tag, in the message.
+func parseSyslog3164(rest string, now time.Time, location *time.Location, data map[string]interface{}) time.Time {
+	timestamp := now
+	if len(rest) > len(syslogStampLayout) {
+		if parsed, err := time.ParseInLocation(syslogStampLayout, rest[:len(syslogStampLayout)], location); err == nil {
+			local := now.In(location)
+			timestamp = time.Date(local.Year(), parsed.Month(), parsed.Day(), parsed.Hour(), parsed.Minute(), parsed.Second(), 0, location)
+			if timestamp.After(local.Add(24 * time.Hour)) {
+				timestamp = timestamp.AddDate(-1, 0, 0)
+			}
+
+			var hostname string
+			hostname, rest = syslogField(strings.TrimLeft(rest[len(syslogStampLayout):], " "))
+			data["hostname"] = hostname
+		}
+	}
+
+	// The tag is the leading word ending in a colon, optionally with the process id in square brackets.
+	if colon := strings.IndexByte(rest, ':'); colon > 0 && !strings.ContainsAny(rest[:colon], " \t") {
+		tag := rest[:colon]
+		if open := strings.IndexByte(tag, '['); open > 0 && strings.HasSuffix(tag, "]") {
+			data["procid"] = tag[open+1 : len(tag)-1]
+			tag = tag[:open]
+		}
+		data["app_name"] = tag
+		rest = strings.TrimPrefix(rest[colon+1:], " ")
+	}
+
+	data["message"] = rest
+	return timestamp
+}
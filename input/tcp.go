@@ -7,8 +7,8 @@ import (
 	"bufio"
 	"errors"
 	"net"
-	"time"
 
+	"github.com/Supernomad/protond/codec"
 	"github.com/Supernomad/protond/common"
 )
 
@@ -16,7 +16,8 @@ import (
 type TCP struct {
 	config       *common.Config
 	pluginConfig *common.PluginConfig
-	messages     chan string
+	codec        codec.Codec
+	messages     chan *common.Event
 	listener     *net.TCPListener
 }
 
@@ -38,30 +39,22 @@ func (tcp *TCP) handleConn(conn *net.TCPConn) {
 
 	reader := bufio.NewReader(conn)
 	for {
-		message, err := reader.ReadString('\n')
+		message, err := codec.ReadFrame(tcp.codec, reader)
 		if err != nil {
 			tcp.config.Log.Debug.Println("[TCP]", "Error reading from connection with the tcp plugin, considering connection dead and moving on.")
 			break
 		}
 
 		tcp.config.Log.Debug.Println("[TCP]", "New tcp message received.")
-		tcp.messages <- message
+		for _, event := range decode(tcp.codec, tcp.pluginConfig.Name, message) {
+			tcp.messages <- event
+		}
 	}
 }
 
 // Next will return the next event from the internal event buffer.
 func (tcp *TCP) Next() (*common.Event, error) {
-	text := <-tcp.messages
-
-	event := &common.Event{
-		Timestamp: time.Now(),
-		Input:     tcp.pluginConfig.Name,
-		Data: map[string]interface{}{
-			"message": text[:len(text)-1],
-		},
-	}
-
-	return event, nil
+	return <-tcp.messages, nil
 }
 
 // Name returns 'TCP'.
@@ -103,12 +96,17 @@ func newTCP(config *common.Config, pluginConfig *common.PluginConfig) (Input, er
 	tcp := &TCP{
 		config:       config,
 		pluginConfig: pluginConfig,
-		messages:     make(chan string, config.Backlog),
+		messages:     make(chan *common.Event, config.Backlog),
 	}
 
 	if tcp.pluginConfig.Config["port"] == "" {
 		return nil, errors.New("configuration for the tcp input plugin is missing a port definition")
 	}
 
+	var err error
+	if tcp.codec, err = codec.Configured(codec.PlainCodec, config, pluginConfig); err != nil {
+		return nil, err
+	}
+
 	return tcp, nil
 }
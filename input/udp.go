@@ -0,0 +1,154 @@
+// Copyright (c) 2017 Christian Saide <Supernomad>
+// Licensed under the MPL-2.0, for details see https://github.com/Supernomad/protond/blob/master/LICENSE
+
+package input
+
+import (
+	"errors"
+	"net"
+	"strconv"
+
+	"github.com/Supernomad/protond/codec"
+	"github.com/Supernomad/protond/common"
+)
+
+const (
+	// UDPFailureTag is the tag added to events received by the udp input plugin whose datagram could not be decoded as json, the raw datagram is kept in the 'message' field.
+	UDPFailureTag = codec.JSONFailureTag
+
+	defaultUDPBufferSize = 64 * 1024
+)
+
+// UDP is a struct representing the udp input plugin.
+type UDP struct {
+	config       *common.Config
+	pluginConfig *common.PluginConfig
+	codec        codec.Codec
+	messages     chan *common.Event
+	conn         *net.UDPConn
+
+	bufferSize int
+	readBuffer int
+	group      *net.UDPAddr
+	iface      *net.Interface
+}
+
+func (udp *UDP) read(conn *net.UDPConn) {
+	buf := make([]byte, udp.bufferSize)
+	for {
+		n, addr, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			udp.config.Log.Debug.Println("[UDP]", "Error reading from the udp socket with the udp plugin, considering it closed.")
+			break
+		}
+
+		udp.config.Log.Debug.Println("[UDP]", "New udp datagram received.")
+		for _, event := range decode(udp.codec, udp.pluginConfig.Name, buf[:n]) {
+			if _, ok := event.Data["source"]; !ok {
+				event.Data["source"] = addr.IP.String()
+			}
+			udp.messages <- event
+		}
+	}
+}
+
+// Next will return the next event from the internal event buffer, each datagram is decoded separately.
+func (udp *UDP) Next() (*common.Event, error) {
+	return <-udp.messages, nil
+}
+
+// Name returns the name of the udp input plugin.
+func (udp *UDP) Name() string {
+	return udp.pluginConfig.Name
+}
+
+// Open will start listening for datagrams, joining the configured multicast group if there is one.
+func (udp *UDP) Open() error {
+	var err error
+	if udp.group != nil {
+		udp.conn, err = net.ListenMulticastUDP("udp", udp.iface, udp.group)
+	} else {
+		var addr *net.UDPAddr
+		if addr, err = net.ResolveUDPAddr("udp", udp.pluginConfig.Config["host"]+":"+udp.pluginConfig.Config["port"]); err != nil {
+			return err
+		}
+		udp.conn, err = net.ListenUDP("udp", addr)
+	}
+	if err != nil {
+		return err
+	}
+
+	if udp.readBuffer > 0 {
+		if err := udp.conn.SetReadBuffer(udp.readBuffer); err != nil {
+			udp.conn.Close()
+			return err
+		}
+	}
+
+	udp.config.Log.Debug.Printf("[UDP] New udp listener created on %s.", udp.conn.LocalAddr().String())
+	go udp.read(udp.conn)
+
+	return nil
+}
+
+// Close will stop listening for datagrams.
+func (udp *UDP) Close() error {
+	if udp.conn == nil {
+		return nil
+	}
+
+	err := udp.conn.Close()
+	udp.conn = nil
+	return err
+}
+
+func newUDP(config *common.Config, pluginConfig *common.PluginConfig) (Input, error) {
+	udp := &UDP{
+		config:       config,
+		pluginConfig: pluginConfig,
+		messages:     make(chan *common.Event, config.Backlog),
+		bufferSize:   defaultUDPBufferSize,
+	}
+
+	if pluginConfig.Config["port"] == "" {
+		return nil, errors.New("configuration for the udp input plugin, '" + pluginConfig.Name + "', is missing a port definition")
+	}
+
+	// The 'json' key predates codecs, and selects the json codec unless a codec is configured.
+	defaultCodec := codec.PlainCodec
+	if pluginConfig.Config["json"] == "true" {
+		defaultCodec = codec.JSONCodec
+	}
+
+	var err error
+	if udp.codec, err = codec.Configured(defaultCodec, config, pluginConfig); err != nil {
+		return nil, err
+	}
+
+	if raw := pluginConfig.Config["buffer_size"]; raw != "" {
+		if udp.bufferSize, err = strconv.Atoi(raw); err != nil || udp.bufferSize <= 0 {
+			return nil, errors.New("configuration for the udp input plugin, '" + pluginConfig.Name + "', has an invalid buffer_size definition, expected a positive 'int'")
+		}
+	}
+
+	if raw := pluginConfig.Config["read_buffer"]; raw != "" {
+		if udp.readBuffer, err = strconv.Atoi(raw); err != nil || udp.readBuffer <= 0 {
+			return nil, errors.New("configuration for the udp input plugin, '" + pluginConfig.Name + "', has an invalid read_buffer definition, expected a positive 'int'")
+		}
+	}
+
+	if raw := pluginConfig.Config["multicast_group"]; raw != "" {
+		udp.group, err = net.ResolveUDPAddr("udp", raw+":"+pluginConfig.Config["port"])
+		if err != nil || !udp.group.IP.IsMulticast() {
+			return nil, errors.New("configuration for the udp input plugin, '" + pluginConfig.Name + "', has an invalid multicast_group definition, expected a multicast ip address")
+		}
+
+		if name := pluginConfig.Config["interface"]; name != "" {
+			if udp.iface, err = net.InterfaceByName(name); err != nil {
+				return nil, errors.New("configuration for the udp input plugin, '" + pluginConfig.Name + "', has an invalid interface definition: " + err.Error())
+			}
+		}
+	}
+
+	return udp, nil
+}
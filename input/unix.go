@@ -0,0 +1,278 @@
+// Copyright (c) 2017 Christian Saide <Supernomad>
+// Licensed under the MPL-2.0, for details see https://github.com/Supernomad/protond/blob/master/LICENSE
+
+package input
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"os"
+	"path"
+	"strconv"
+
+	"github.com/Supernomad/protond/codec"
+	"github.com/Supernomad/protond/common"
+)
+
+const defaultSocketMode = 0660
+
+// socketConfig is the configuration shared by the unix and unixgram input plugins.
+type socketConfig struct {
+	path string
+	mode os.FileMode
+}
+
+func newSocketConfig(plugin string, pluginConfig *common.PluginConfig) (*socketConfig, error) {
+	socket := &socketConfig{
+		path: pluginConfig.Config["path"],
+		mode: defaultSocketMode,
+	}
+
+	if socket.path == "" {
+		return nil, errors.New("configuration for the " + plugin + " input plugin, '" + pluginConfig.Name + "', is missing a path definition")
+	}
+
+	if raw := pluginConfig.Config["mode"]; raw != "" {
+		mode, err := strconv.ParseUint(raw, 8, 32)
+		if err != nil || mode > 0777 {
+			return nil, errors.New("configuration for the " + plugin + " input plugin, '" + pluginConfig.Name + "', has an invalid mode definition, expected an octal permission for example: '0660'")
+		}
+		socket.mode = os.FileMode(mode)
+	}
+
+	return socket, nil
+}
+
+// prepare removes a stale socket file left behind by a previous process that didn't shut down cleanly, a socket that is still accepting connections or a path that isn't a socket is left alone.
+func (socket *socketConfig) prepare(network string) error {
+	info, err := os.Lstat(socket.path)
+	if os.IsNotExist(err) {
+		return os.MkdirAll(path.Dir(socket.path), 0755)
+	} else if err != nil {
+		return err
+	}
+
+	if info.Mode()&os.ModeSocket == 0 {
+		return errors.New("the path '" + socket.path + "' already exists and is not a socket")
+	}
+
+	if conn, err := net.Dial(network, socket.path); err == nil {
+		conn.Close()
+		return errors.New("the socket '" + socket.path + "' is already in use by another process")
+	}
+
+	return os.Remove(socket.path)
+}
+
+// cleanup removes the socket file, if it still exists.
+func (socket *socketConfig) cleanup() error {
+	if err := os.Remove(socket.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Unix is a struct representing the unix stream socket input plugin.
+type Unix struct {
+	config       *common.Config
+	pluginConfig *common.PluginConfig
+	socket       *socketConfig
+	codec        codec.Codec
+	messages     chan *common.Event
+	listener     *net.UnixListener
+}
+
+func (unix *Unix) accept(listener *net.UnixListener) {
+	for {
+		conn, err := listener.AcceptUnix()
+		if err != nil {
+			unix.config.Log.Error.Println("[UNIX]", "Error accepting new connections with the unix plugin.")
+			break
+		}
+
+		unix.config.Log.Debug.Println("[UNIX]", "New unix connection received.")
+		go unix.handleConn(conn)
+	}
+}
+
+func (unix *Unix) handleConn(conn *net.UnixConn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	for {
+		message, err := codec.ReadFrame(unix.codec, reader)
+		if err != nil {
+			unix.config.Log.Debug.Println("[UNIX]", "Error reading from connection with the unix plugin, considering connection dead and moving on.")
+			break
+		}
+
+		unix.config.Log.Debug.Println("[UNIX]", "New unix message received.")
+		for _, event := range decode(unix.codec, unix.pluginConfig.Name, message) {
+			unix.messages <- event
+		}
+	}
+}
+
+// Next will return the next event from the internal event buffer.
+func (unix *Unix) Next() (*common.Event, error) {
+	return <-unix.messages, nil
+}
+
+// Name returns the name of the unix input plugin.
+func (unix *Unix) Name() string {
+	return unix.pluginConfig.Name
+}
+
+// Open will remove any stale socket file and start listening on the configured socket.
+func (unix *Unix) Open() error {
+	if err := unix.socket.prepare("unix"); err != nil {
+		return err
+	}
+
+	l, err := net.ListenUnix("unix", &net.UnixAddr{Name: unix.socket.path, Net: "unix"})
+	if err != nil {
+		return err
+	}
+
+	if err := os.Chmod(unix.socket.path, unix.socket.mode); err != nil {
+		l.Close()
+		return err
+	}
+
+	unix.config.Log.Debug.Printf("[UNIX] New unix listener created on %s.", unix.socket.path)
+	unix.listener = l
+
+	go unix.accept(l)
+
+	return nil
+}
+
+// Close will stop listening on the socket and remove the socket file.
+func (unix *Unix) Close() error {
+	if unix.listener == nil {
+		return nil
+	}
+
+	err := unix.listener.Close()
+	unix.listener = nil
+	if cleanupErr := unix.socket.cleanup(); err == nil {
+		err = cleanupErr
+	}
+	return err
+}
+
+func newUnix(config *common.Config, pluginConfig *common.PluginConfig) (Input, error) {
+	socket, err := newSocketConfig(UnixInput, pluginConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	unix := &Unix{
+		config:       config,
+		pluginConfig: pluginConfig,
+		socket:       socket,
+		messages:     make(chan *common.Event, config.Backlog),
+	}
+
+	if unix.codec, err = codec.Configured(codec.PlainCodec, config, pluginConfig); err != nil {
+		return nil, err
+	}
+
+	return unix, nil
+}
+
+// UnixGram is a struct representing the unix datagram socket input plugin.
+type UnixGram struct {
+	config       *common.Config
+	pluginConfig *common.PluginConfig
+	socket       *socketConfig
+	codec        codec.Codec
+	messages     chan *common.Event
+	conn         *net.UnixConn
+}
+
+func (unixgram *UnixGram) read(conn *net.UnixConn) {
+	buf := make([]byte, defaultUDPBufferSize)
+	for {
+		n, _, err := conn.ReadFromUnix(buf)
+		if err != nil {
+			unixgram.config.Log.Debug.Println("[UNIXGRAM]", "Error reading from the socket with the unixgram plugin, considering it closed.")
+			break
+		}
+
+		unixgram.config.Log.Debug.Println("[UNIXGRAM]", "New unixgram datagram received.")
+		for _, event := range decode(unixgram.codec, unixgram.pluginConfig.Name, buf[:n]) {
+			unixgram.messages <- event
+		}
+	}
+}
+
+// Next will return the next event from the internal event buffer, each datagram is decoded separately.
+func (unixgram *UnixGram) Next() (*common.Event, error) {
+	return <-unixgram.messages, nil
+}
+
+// Name returns the name of the unixgram input plugin.
+func (unixgram *UnixGram) Name() string {
+	return unixgram.pluginConfig.Name
+}
+
+// Open will remove any stale socket file and start reading datagrams from the configured socket.
+func (unixgram *UnixGram) Open() error {
+	if err := unixgram.socket.prepare("unixgram"); err != nil {
+		return err
+	}
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: unixgram.socket.path, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+
+	if err := os.Chmod(unixgram.socket.path, unixgram.socket.mode); err != nil {
+		conn.Close()
+		unixgram.socket.cleanup()
+		return err
+	}
+
+	unixgram.config.Log.Debug.Printf("[UNIXGRAM] New unixgram listener created on %s.", unixgram.socket.path)
+	unixgram.conn = conn
+
+	go unixgram.read(conn)
+
+	return nil
+}
+
+// Close will stop reading from the socket and remove the socket file.
+func (unixgram *UnixGram) Close() error {
+	if unixgram.conn == nil {
+		return nil
+	}
+
+	err := unixgram.conn.Close()
+	unixgram.conn = nil
+	if cleanupErr := unixgram.socket.cleanup(); err == nil {
+		err = cleanupErr
+	}
+	return err
+}
+
+func newUnixGram(config *common.Config, pluginConfig *common.PluginConfig) (Input, error) {
+	socket, err := newSocketConfig(UnixGramInput, pluginConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	unixgram := &UnixGram{
+		config:       config,
+		pluginConfig: pluginConfig,
+		socket:       socket,
+		messages:     make(chan *common.Event, config.Backlog),
+	}
+
+	if unixgram.codec, err = codec.Configured(codec.PlainCodec, config, pluginConfig); err != nil {
+		return nil, err
+	}
+
+	return unixgram, nil
+}
@@ -4,8 +4,11 @@
 package main
 
 import (
+	"errors"
 	"os"
+	"strconv"
 
+	"github.com/Supernomad/protond/alert"
 	"github.com/Supernomad/protond/cache"
 	"github.com/Supernomad/protond/common"
 	"github.com/Supernomad/protond/filter"
@@ -32,14 +35,30 @@ func main() {
 	config, err := common.NewConfig(log)
 	handleError(config.Log, err)
 
-	internalCache, err := cache.New(cache.MemoryCache, config, &common.PluginConfig{Name: "memory"})
+	internalCache, err := cache.New(config.CacheType, config, &common.PluginConfig{
+		Name: config.CacheType,
+		Type: config.CacheType,
+		Config: map[string]string{
+			"ttl":        config.CacheTTL.String(),
+			"max_length": strconv.Itoa(config.CacheMaxLength),
+			"max_keys":   strconv.Itoa(config.CacheMaxKeys),
+		},
+	})
 	handleError(config.Log, err)
 
 	workers := make([]*worker.Worker, config.NumWorkers)
 
+	alerts := make(map[string]alert.Alert)
+	for i := 0; i < len(config.Alerts); i++ {
+		temp, err := alert.New(config.Alerts[i].Type, config, config.Alerts[i])
+		handleError(config.Log, err)
+
+		alerts[config.Alerts[i].Name] = temp
+	}
+
 	filters := make([]filter.Filter, 0)
 	for i := 0; i < len(config.Filters); i++ {
-		temp, err := filter.New(config.Filters[i].Type, config, config.Filters[i], internalCache, nil)
+		temp, err := filter.New(config.Filters[i].Type, config, config.Filters[i], internalCache, alerts)
 		handleError(config.Log, err)
 
 		filters = append(filters, temp)
@@ -50,11 +69,32 @@ func main() {
 		filters = append(filters, noop)
 	}
 
+	pipelines := make(map[string][]filter.Filter)
+	for name, filterConfigs := range config.Pipelines {
+		pipeline := make([]filter.Filter, 0)
+		for i := 0; i < len(filterConfigs); i++ {
+			temp, err := filter.New(filterConfigs[i].Type, config, filterConfigs[i], internalCache, alerts)
+			handleError(config.Log, err)
+
+			pipeline = append(pipeline, temp)
+		}
+		pipelines[name] = pipeline
+	}
+
 	inputs := make([]input.Input, 0)
+	chains := make(map[string][]filter.Filter)
 	for i := 0; i < len(config.Inputs); i++ {
 		temp, err := input.New(config.Inputs[i].Type, config, config.Inputs[i])
 		handleError(config.Log, err)
 
+		if name := config.Inputs[i].Config["pipeline"]; name != "" {
+			pipeline, ok := pipelines[name]
+			if !ok {
+				handleError(config.Log, errors.New("input '"+config.Inputs[i].Name+"' references the pipeline '"+name+"' which does not exist in the filter directory"))
+			}
+			chains[temp.Name()] = pipeline
+		}
+
 		err = temp.Open()
 		handleError(config.Log, err)
 
@@ -83,7 +123,7 @@ func main() {
 	}
 
 	for i := 0; i < config.NumWorkers; i++ {
-		workers[i] = worker.New(config, inputs, filters, outputs)
+		workers[i] = worker.New(config, i, inputs, filters, chains, outputs)
 		workers[i].Start()
 	}
 
@@ -97,4 +137,7 @@ func main() {
 	for i := 0; i < config.NumWorkers; i++ {
 		workers[i].Stop()
 	}
+
+	err = internalCache.Close()
+	handleError(config.Log, err)
 }
@@ -9,5 +9,24 @@ Protond currently implements the following output plugins:
     - This plugin writes to stdout and is used for testing filters and other pieces of functionality of protond.
   - TCP
     - This plugin allows connecting to an arbitrary tcp server, and pushes events over the connection.
+  - Http
+    - This plugin POSTs events as json blobs to an arbitrary http server.
+
+The Stdout, TCP, and Http plugins encode events with the codec named by the 'codec' configuration key, see the codec package for the available codecs and their options.
+The Stdout plugin defaults to the 'json' codec which pretty prints events, and the TCP and Http plugins default to the 'json_lines' codec which writes compact json followed by a new line.
+
+Every output plugin can be limited to a subset of events with the following routing conditions, all of which must match:
+  - match_input
+    - A comma separated list of input names the event must have come from.
+  - match_field
+    - A comma separated list of 'field=value' pairs the event data must contain.
+  - match_regex
+    - A single 'field=pattern' pair where the field value must match the regular expression.
+  - match_tag
+    - A comma separated list of tags, of which the event must have at least one.
+  - if
+    - A conditional expression the event must satisfy, see the expression package for the syntax, for example 'status >= 500 && @input == "nginx"'.
+
+Filters can also set an explicit list of output names on an event, which takes precedence over the routing conditions.
 */
 package output
@@ -9,20 +9,35 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/Supernomad/protond/codec"
 	"github.com/Supernomad/protond/common"
 )
 
+// contentTypes maps codecs to the content type of the requests they encode, codecs that aren't listed are sent as plain text.
+var contentTypes = map[string]string{
+	codec.JSONCodec:      "application/json",
+	codec.JSONLinesCodec: "application/json",
+	codec.MsgpackCodec:   "application/msgpack",
+	codec.CSVCodec:       "text/csv",
+}
+
 // HTTP is a struct representing the http output plugin.
 type HTTP struct {
 	config       *common.Config
 	pluginConfig *common.PluginConfig
+	codec        codec.Codec
+	contentType  string
 	uri          string
 }
 
 // Send takes the passed in event and sends it to the remote server.
 func (h *HTTP) Send(event *common.Event) error {
-	buf := event.Bytes(false)
-	resp, err := http.Post(h.uri, "application/json", bytes.NewBuffer(buf))
+	buf, err := h.codec.Encode(event)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.Post(h.uri, h.contentType, bytes.NewBuffer(buf))
 
 	if err != nil {
 		return err
@@ -75,5 +90,15 @@ func newHTTP(config *common.Config, pluginConfig *common.PluginConfig) (Output,
 		h.pluginConfig.Config["route"] = "/"
 	}
 
+	var err error
+	if h.codec, err = codec.Configured(codec.JSONLinesCodec, config, pluginConfig); err != nil {
+		return nil, err
+	}
+
+	h.contentType = contentTypes[h.codec.Name()]
+	if h.contentType == "" {
+		h.contentType = "text/plain"
+	}
+
 	return h, nil
 }
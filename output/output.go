@@ -38,17 +38,27 @@ type Output interface {
 	Close() error
 }
 
-// New generates an output plugin based on the passed in plugin and user defined configuration.
+// New generates an output plugin based on the passed in plugin and user defined configuration, configured plugins are wrapped in a Route which applies any of the 'match_input', 'match_field', 'match_regex', or 'match_tag' routing conditions.
 func New(outputPlugin string, config *common.Config, pluginConfig *common.PluginConfig) (Output, error) {
+	var output Output
+	var err error
+
 	switch outputPlugin {
 	case NoopOutput:
-		return newNoop(config)
+		output, err = newNoop(config)
 	case StdoutOutput:
-		return newStdout(config)
+		output, err = newStdout(config, pluginConfig)
 	case TCPOutput:
-		return newTCP(config, pluginConfig)
+		output, err = newTCP(config, pluginConfig)
 	case HTTPOutput:
-		return newHTTP(config, pluginConfig)
+		output, err = newHTTP(config, pluginConfig)
+	default:
+		return nil, errors.New("specified output plugin does not exist")
+	}
+
+	if err != nil {
+		return nil, err
 	}
-	return nil, errors.New("specified output plugin does not exist")
+
+	return newRoute(output, pluginConfig)
 }
@@ -2,10 +2,15 @@ package output
 
 import (
 	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strconv"
 	"testing"
 	"time"
 
+	"github.com/Supernomad/protond/codec"
 	"github.com/Supernomad/protond/common"
 	"github.com/Supernomad/protond/input"
 )
@@ -196,3 +201,171 @@ func TestHTTP(t *testing.T) {
 	}
 	time.Sleep(1 * time.Second)
 }
+
+func TestRoute(t *testing.T) {
+	config := &common.Config{Backlog: 1024, Log: common.NewLogger(common.NoopLogger)}
+
+	route, err := New(NoopOutput, config, &common.PluginConfig{Name: "Testing Route", Type: "noop", Config: map[string]string{"match_field": "woot"}})
+	if err == nil || route != nil {
+		t.Fatal("output plugin did not throw an error when configured with an invalid match_field.")
+	}
+
+	route, err = New(NoopOutput, config, &common.PluginConfig{Name: "Testing Route", Type: "noop", Config: map[string]string{"match_regex": "message=("}})
+	if err == nil || route != nil {
+		t.Fatal("output plugin did not throw an error when configured with an invalid match_regex.")
+	}
+
+	route, err = New(NoopOutput, config, &common.PluginConfig{Name: "Testing Route", Type: "noop", Config: map[string]string{"if": "level =="}})
+	if err == nil || route != nil {
+		t.Fatal("output plugin did not throw an error when configured with an invalid if condition.")
+	}
+
+	route, err = New(NoopOutput, config, &common.PluginConfig{Name: "Testing Route", Type: "noop", Config: map[string]string{}})
+	if err != nil {
+		t.Fatalf("output plugin threw an error for no reason: %s", err.Error())
+	}
+	if route.Name() != "Testing Route" || !Accepts(route, &common.Event{Data: map[string]interface{}{}}) {
+		t.Fatal("output route without any routing conditions didn't use the configured name or didn't accept an event.")
+	}
+
+	route, err = New(NoopOutput, config, &common.PluginConfig{
+		Name: "Testing Route",
+		Type: "noop",
+		Config: map[string]string{
+			"match_input": "syslog, http",
+			"match_field": "level=error, service.name=api",
+			"match_regex": "message=^audit:",
+			"match_tag":   "audit,security",
+			"if":          "!exists(debug)",
+		},
+	})
+	if err != nil {
+		t.Fatalf("output plugin threw an error for no reason: %s", err.Error())
+	}
+
+	event := &common.Event{
+		Timestamp: time.Now(),
+		Input:     "syslog",
+		Tags:      []string{"security"},
+		Data: map[string]interface{}{
+			"message": "audit: user logged in",
+			"level":   "error",
+			"service": map[string]interface{}{"name": "api"},
+		},
+	}
+
+	if !Accepts(route, event) {
+		t.Fatal("output route did not accept an event matching all of its conditions.")
+	}
+
+	event.Input = "tcp"
+	if Accepts(route, event) {
+		t.Fatal("output route accepted an event from an input it doesn't match.")
+	}
+	event.Input = "http"
+
+	event.Data["level"] = "info"
+	if Accepts(route, event) {
+		t.Fatal("output route accepted an event with a field value it doesn't match.")
+	}
+	event.Data["level"] = "error"
+
+	event.Data["message"] = "user logged in"
+	if Accepts(route, event) {
+		t.Fatal("output route accepted an event with a field that doesn't match its regex.")
+	}
+	event.Data["message"] = "audit: user logged in"
+
+	event.Data["debug"] = true
+	if Accepts(route, event) {
+		t.Fatal("output route accepted an event that doesn't satisfy its if condition.")
+	}
+	delete(event.Data, "debug")
+
+	event.Tags = []string{"metrics"}
+	if Accepts(route, event) {
+		t.Fatal("output route accepted an event without any of its tags.")
+	}
+
+	event.Routes = []string{"Testing Route"}
+	if !Accepts(route, event) {
+		t.Fatal("output route did not accept an event explicitly routed to it.")
+	}
+
+	noop, _ := New(NoopOutput, config, nil)
+	if Accepts(noop, event) {
+		t.Fatal("output accepted an event explicitly routed to a different output.")
+	}
+
+	event.Routes = nil
+	if !Accepts(noop, event) {
+		t.Fatal("output without routing conditions didn't accept an event.")
+	}
+}
+
+func TestCodec(t *testing.T) {
+	config := &common.Config{Backlog: 1024, Log: common.NewLogger(common.NoopLogger)}
+
+	for _, plugin := range []string{StdoutOutput, TCPOutput, HTTPOutput} {
+		out, err := New(plugin, config, &common.PluginConfig{Name: "Testing Codec", Type: plugin, Config: map[string]string{"host": "127.0.0.1", "port": "9099", "codec": "doesn't exist"}})
+		if err == nil || out != nil {
+			t.Fatalf("%s plugin did not throw an error when configured with an invalid codec.", plugin)
+		}
+	}
+
+	file, _ := ioutil.TempFile(os.TempDir(), "stdout")
+	defer os.Remove(file.Name())
+	os.Setenv("_TESTING_PROTOND", file.Name())
+
+	event := &common.Event{
+		Timestamp: time.Date(2017, time.March, 1, 22, 14, 15, 0, time.UTC),
+		Input:     "test",
+		Data: map[string]interface{}{
+			"message": "hello world",
+			"status":  200,
+		},
+	}
+
+	stdout, err := New(StdoutOutput, config, &common.PluginConfig{Name: "Testing Codec", Type: "stdout", Config: map[string]string{"codec": "logfmt"}})
+	if err != nil {
+		t.Fatalf("stdout plugin threw an error for no reason: %s", err.Error())
+	}
+	if err := stdout.Send(event); err != nil {
+		t.Fatalf("Something is very very wrong: %s", err.Error())
+	}
+
+	written, _ := ioutil.ReadFile(file.Name())
+	if string(written) != "timestamp=2017-03-01T22:14:15Z input=test message=\"hello world\" status=200\n" {
+		t.Fatalf("stdout plugin did not write the event with the configured codec: %q", written)
+	}
+
+	requests := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests <- r
+		bodies <- body
+	}))
+	defer server.Close()
+
+	addr := server.Listener.Addr().(*net.TCPAddr)
+	h, err := New(HTTPOutput, config, &common.PluginConfig{Name: "Testing Codec", Type: "http", Config: map[string]string{"host": "127.0.0.1", "port": strconv.Itoa(addr.Port), "codec": "msgpack"}})
+	if err != nil {
+		t.Fatalf("http plugin threw an error for no reason: %s", err.Error())
+	}
+	h.Open()
+
+	if err := h.Send(event); err != nil {
+		t.Fatalf("Something is very very wrong: %s", err.Error())
+	}
+
+	if r := <-requests; r.Header.Get("Content-Type") != "application/msgpack" {
+		t.Fatalf("http plugin sent the wrong content type for the msgpack codec: %s", r.Header.Get("Content-Type"))
+	}
+
+	msgpack, _ := codec.New(codec.MsgpackCodec, nil, nil)
+	records, err := msgpack.Decode(<-bodies)
+	if err != nil || len(records) != 1 || records[0]["data"].(map[string]interface{})["message"] != "hello world" {
+		t.Fatalf("http plugin did not send the event with the configured codec: %v", records)
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2017 Christian Saide <Supernomad>
+// Licensed under the MPL-2.0, for details see https://github.com/Supernomad/protond/blob/master/LICENSE
+
+package output
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Supernomad/protond/common"
+	"github.com/Supernomad/protond/expression"
+)
+
+// Route is a struct that wraps an arbitrary output plugin, and only accepts the events matching the routing conditions defined in the plugins configuration.
+type Route struct {
+	Output
+
+	name   string
+	inputs []string
+	fields map[string]string
+	field  string
+	regex  *regexp.Regexp
+	tags   []string
+	cond   *expression.Expression
+}
+
+func contains(list []string, value string) bool {
+	for i := 0; i < len(list); i++ {
+		if list[i] == value {
+			return true
+		}
+	}
+	return false
+}
+
+func splitList(raw string) []string {
+	list := make([]string, 0)
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// Name returns the configured name of the output plugin, which is the name filters use to explicitly route events to it.
+func (route *Route) Name() string {
+	if route.name != "" {
+		return route.name
+	}
+	return route.Output.Name()
+}
+
+// Match determines whether or not the supplied event satisfies every routing condition configured for the output.
+func (route *Route) Match(event *common.Event) bool {
+	if len(route.inputs) > 0 && !contains(route.inputs, event.Input) {
+		return false
+	}
+
+	for field, expected := range route.fields {
+		value, ok := event.Field(field)
+		if !ok || fmt.Sprint(value) != expected {
+			return false
+		}
+	}
+
+	if route.regex != nil {
+		value, ok := event.Field(route.field)
+		if !ok || !route.regex.MatchString(fmt.Sprint(value)) {
+			return false
+		}
+	}
+
+	if route.cond != nil && !route.cond.Match(event) {
+		return false
+	}
+
+	if len(route.tags) > 0 {
+		for i := 0; i < len(route.tags); i++ {
+			if event.HasTag(route.tags[i]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+// Accepts determines whether or not the supplied output should receive the supplied event, an explicit route list set on the event by a filter takes precedence over the routing conditions of the output.
+func Accepts(output Output, event *common.Event) bool {
+	if len(event.Routes) > 0 {
+		return contains(event.Routes, output.Name())
+	}
+
+	if route, ok := output.(*Route); ok {
+		return route.Match(event)
+	}
+	return true
+}
+
+func newRoute(output Output, pluginConfig *common.PluginConfig) (Output, error) {
+	if pluginConfig == nil {
+		return output, nil
+	}
+
+	route := &Route{
+		Output: output,
+		name:   pluginConfig.Name,
+		inputs: splitList(pluginConfig.Config["match_input"]),
+		fields: make(map[string]string),
+		tags:   splitList(pluginConfig.Config["match_tag"]),
+	}
+
+	for _, pair := range splitList(pluginConfig.Config["match_field"]) {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, errors.New("configuration for the output plugin, '" + pluginConfig.Name + "', has an invalid match_field definition, expected 'field=value' got '" + pair + "'")
+		}
+		route.fields[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+
+	if raw := pluginConfig.Config["match_regex"]; raw != "" {
+		parts := strings.SplitN(raw, "=", 2)
+		if len(parts) != 2 {
+			return nil, errors.New("configuration for the output plugin, '" + pluginConfig.Name + "', has an invalid match_regex definition, expected 'field=pattern' got '" + raw + "'")
+		}
+
+		regex, err := regexp.Compile(parts[1])
+		if err != nil {
+			return nil, errors.New("configuration for the output plugin, '" + pluginConfig.Name + "', has an invalid match_regex pattern: " + err.Error())
+		}
+		route.field = strings.TrimSpace(parts[0])
+		route.regex = regex
+	}
+
+	if raw := pluginConfig.Config["if"]; raw != "" {
+		cond, err := expression.Compile(raw)
+		if err != nil {
+			return nil, errors.New("configuration for the output plugin, '" + pluginConfig.Name + "', has an invalid if condition: " + err.Error())
+		}
+		route.cond = cond
+	}
+
+	return route, nil
+}
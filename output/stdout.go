@@ -7,6 +7,7 @@ import (
 	"bufio"
 	"os"
 
+	"github.com/Supernomad/protond/codec"
 	"github.com/Supernomad/protond/common"
 )
 
@@ -15,14 +16,18 @@ type Stdout struct {
 	config *common.Config
 	name   string
 	writer *bufio.Writer
+	codec  codec.Codec
 }
 
 // Send writes the supplied event to standard output.
 func (stdout *Stdout) Send(event *common.Event) error {
-	str := event.String(true)
+	buf, err := stdout.codec.Encode(event)
+	if err != nil {
+		return err
+	}
 
-	n, err := stdout.writer.WriteString(str + "\n")
-	if err != nil || len(str)+1 != n {
+	n, err := stdout.writer.Write(buf)
+	if err != nil || len(buf) != n {
 		return err
 	}
 
@@ -44,12 +49,17 @@ func (stdout *Stdout) Close() error {
 	return nil
 }
 
-func newStdout(config *common.Config) (Output, error) {
+func newStdout(config *common.Config, pluginConfig *common.PluginConfig) (Output, error) {
 	stdout := &Stdout{
 		config: config,
 		name:   "Stdout",
 	}
 
+	var err error
+	if stdout.codec, err = codec.Configured(codec.JSONCodec, config, pluginConfig); err != nil {
+		return nil, err
+	}
+
 	if tmpFile := os.Getenv("_TESTING_PROTOND"); tmpFile != "" {
 		file, _ := os.OpenFile(tmpFile, os.O_APPEND|os.O_RDWR, os.ModeAppend)
 
@@ -9,6 +9,7 @@ import (
 	"net"
 	"time"
 
+	"github.com/Supernomad/protond/codec"
 	"github.com/Supernomad/protond/common"
 )
 
@@ -16,6 +17,7 @@ import (
 type TCP struct {
 	config       *common.Config
 	pluginConfig *common.PluginConfig
+	codec        codec.Codec
 	conn         *net.TCPConn
 	writer       *bufio.Writer
 }
@@ -60,13 +62,16 @@ func (tcp *TCP) Send(event *common.Event) (err error) {
 		return
 	}()
 
-	str := event.String(false)
+	buf, err := tcp.codec.Encode(event)
+	if err != nil {
+		return err
+	}
 
-	n, err := tcp.writer.WriteString(str + "\n")
+	n, err := tcp.writer.Write(buf)
 	if err != nil {
 		return err
 	}
-	if len(str)+1 != n {
+	if len(buf) != n {
 		return errors.New("failed writing the entire event to the remote tcp server")
 	}
 
@@ -118,5 +123,10 @@ func newTCP(config *common.Config, pluginConfig *common.PluginConfig) (Output, e
 		return nil, errors.New("configuration for the tcp output plugin is missing a port definition")
 	}
 
+	var err error
+	if tcp.codec, err = codec.Configured(codec.JSONLinesCodec, config, pluginConfig); err != nil {
+		return nil, err
+	}
+
 	return tcp, nil
 }
@@ -4,6 +4,9 @@
 package worker
 
 import (
+	"sync/atomic"
+	"time"
+
 	"github.com/Supernomad/protond/common"
 	"github.com/Supernomad/protond/filter"
 	"github.com/Supernomad/protond/input"
@@ -13,6 +16,10 @@ import (
 // Worker represents an individual protond worker.
 type Worker struct {
 	config *common.Config
+	id     int
+
+	dropped uint64
+	errored uint64
 
 	incoming chan *common.Event
 	outgoing chan *common.Event
@@ -21,9 +28,10 @@ type Worker struct {
 	stopFiltering chan struct{}
 	stopWriting   chan struct{}
 
-	filters []filter.Filter
-	inputs  []input.Input
-	outputs []output.Output
+	filters   []filter.Filter
+	pipelines map[string][]filter.Filter
+	inputs    []input.Input
+	outputs   []output.Output
 }
 
 func (w *Worker) input(input int) {
@@ -37,29 +45,61 @@ func (w *Worker) input(input int) {
 			if err != nil {
 				w.config.Log.Error.Printf("errored getting next event from input '%s'\nerror: %s", w.inputs[input].Name(), err.Error())
 			} else {
+				event.Worker = w.id
+				if event.Received.IsZero() {
+					event.Received = time.Now()
+				}
 				w.incoming <- event
 			}
 		}
 	}
 }
 
+// process runs the supplied event through the supplied filters, fanning out any events a filter splits the event into through the remaining filters, and queues the results for output.
+func (w *Worker) process(event *common.Event, filters []filter.Filter) {
+	for i := 0; i < len(filters); i++ {
+		events, err := filter.Apply(filters[i], event)
+		if err != nil {
+			atomic.AddUint64(&w.errored, 1)
+			w.config.Log.Error.Printf("errored running filter '%s' on event: %s\nerror: %s", filters[i].Name(), event.String(false), err.Error())
+			return
+		}
+
+		switch len(events) {
+		case 0:
+			atomic.AddUint64(&w.dropped, 1)
+			w.config.Log.Debug.Printf("filter '%s' dropped event", filters[i].Name())
+			return
+		case 1:
+			event = events[0]
+		default:
+			for j := 0; j < len(events); j++ {
+				w.process(events[j], filters[i+1:])
+			}
+			return
+		}
+	}
+
+	w.outgoing <- event
+}
+
 func (w *Worker) filter() {
+	incoming := w.incoming
 	for {
 		select {
-		case event := <-w.incoming:
-			var err error
-
-			for i := 0; i < len(w.filters); i++ {
-				event, err = w.filters[i].Run(event)
-				if err != nil {
-					w.config.Log.Error.Printf("errored running filter '%s' on event: %s\nerror: %s", w.filters[i].Name(), event.String(false), err.Error())
-					break
-				}
+		case event, ok := <-incoming:
+			// Once the inputs have stopped and closed the channel, stop selecting on it and wait to be told to stop.
+			if !ok {
+				incoming = nil
+				continue
 			}
 
-			if err == nil {
-				w.outgoing <- event
+			filters := w.filters
+			if pipeline, ok := w.pipelines[event.Input]; ok {
+				filters = pipeline
 			}
+
+			w.process(event, filters)
 		case <-w.stopFiltering:
 			close(w.stopFiltering)
 			close(w.outgoing)
@@ -69,10 +109,21 @@ func (w *Worker) filter() {
 }
 
 func (w *Worker) output() {
+	outgoing := w.outgoing
 	for {
 		select {
-		case event := <-w.outgoing:
+		case event, ok := <-outgoing:
+			// Once filtering has stopped and closed the channel, stop selecting on it and wait to be told to stop.
+			if !ok {
+				outgoing = nil
+				continue
+			}
+
 			for i := 0; i < len(w.outputs); i++ {
+				if !output.Accepts(w.outputs[i], event) {
+					continue
+				}
+
 				err := w.outputs[i].Send(event)
 				if err != nil {
 					w.config.Log.Error.Printf("errored sending to output '%s' on event: %s\nerror: %s", w.outputs[i].Name(), event.String(false), err.Error())
@@ -85,6 +136,16 @@ func (w *Worker) output() {
 	}
 }
 
+// Dropped returns the number of events that were intentionally discarded by a filter.
+func (w *Worker) Dropped() uint64 {
+	return atomic.LoadUint64(&w.dropped)
+}
+
+// Errored returns the number of events that were discarded because a filter errored while processing them.
+func (w *Worker) Errored() uint64 {
+	return atomic.LoadUint64(&w.errored)
+}
+
 // Start the protond worker, so it will begin processing events.
 func (w *Worker) Start() {
 	for i := 0; i < len(w.inputs); i++ {
@@ -118,12 +179,14 @@ func (w *Worker) Stop() error {
 	return nil
 }
 
-// New returns a worker object that is fully configured and ready to be started.
-func New(config *common.Config, inputs []input.Input, filters []filter.Filter, outputs []output.Output) *Worker {
+// New returns a worker object identified by the supplied id that is fully configured and ready to be started, events from inputs that have an entry in the supplied pipelines map, keyed by input name, are filtered by that chain instead of the default filters.
+func New(config *common.Config, id int, inputs []input.Input, filters []filter.Filter, pipelines map[string][]filter.Filter, outputs []output.Output) *Worker {
 	return &Worker{
 		config:        config,
+		id:            id,
 		inputs:        inputs,
 		filters:       filters,
+		pipelines:     pipelines,
 		outputs:       outputs,
 		incoming:      make(chan *common.Event, config.Backlog),
 		outgoing:      make(chan *common.Event, config.Backlog),
@@ -28,7 +28,7 @@ func TestWorker(t *testing.T) {
 		t.Fatal("Something is very very wrong.")
 	}
 
-	worker := New(config, []input.Input{in}, []filter.Filter{filt}, []output.Output{out})
+	worker := New(config, 0, []input.Input{in}, []filter.Filter{filt}, nil, []output.Output{out})
 
 	worker.Start()
 
@@ -41,3 +41,175 @@ func TestWorker(t *testing.T) {
 
 	time.Sleep(1 * time.Second)
 }
+
+func TestWorkerDrop(t *testing.T) {
+	config := &common.Config{Log: common.NewLogger(common.NoopLogger), Backlog: 1024, FilterTimeout: 10 * time.Second}
+
+	in, err := input.New(input.NoopInput, config, nil)
+	if err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+
+	filt, err := filter.New(filter.JavascriptFilter, config, &common.FilterConfig{Name: "Drop Filter", Code: `drop()`}, nil, nil)
+	if err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+
+	out, err := output.New(output.NoopOutput, config, nil)
+	if err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+
+	worker := New(config, 0, []input.Input{in}, []filter.Filter{filt}, nil, []output.Output{out})
+
+	worker.Start()
+
+	time.Sleep(1 * time.Second)
+
+	err = worker.Stop()
+	if err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+
+	if worker.Dropped() == 0 {
+		t.Fatal("worker did not count the events dropped by the filter.")
+	}
+	if worker.Errored() != 0 {
+		t.Fatal("worker counted dropped events as errors.")
+	}
+	if len(worker.outgoing) != 0 {
+		t.Fatal("worker passed dropped events on to the outputs.")
+	}
+}
+
+type recorder struct {
+	events chan *common.Event
+}
+
+func (r *recorder) Send(event *common.Event) error {
+	r.events <- event
+	return nil
+}
+
+func (r *recorder) Name() string {
+	return "Recorder"
+}
+
+func (r *recorder) Open() error {
+	return nil
+}
+
+func (r *recorder) Close() error {
+	return nil
+}
+
+func TestWorkerSplit(t *testing.T) {
+	config := &common.Config{Log: common.NewLogger(common.NoopLogger), Backlog: 1024, FilterTimeout: 10 * time.Second}
+
+	split, err := filter.New(filter.JavascriptFilter, config, &common.FilterConfig{Name: "Split Filter", Code: `event = [{"message": "first"}, {"message": "second"}]`}, nil, nil)
+	if err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+
+	mark, err := filter.New(filter.JavascriptFilter, config, &common.FilterConfig{Name: "Mark Filter", Code: `event.marked = true`}, nil, nil)
+	if err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+
+	out := &recorder{events: make(chan *common.Event, 10)}
+	worker := New(config, 0, []input.Input{}, []filter.Filter{split, mark}, nil, []output.Output{out})
+
+	worker.Start()
+	worker.incoming <- &common.Event{Timestamp: time.Now(), Data: map[string]interface{}{"message": "batch"}}
+
+	for _, expected := range []string{"first", "second"} {
+		select {
+		case event := <-out.events:
+			if event.Data["message"] != expected || event.Data["marked"] != true {
+				t.Fatal("worker did not fan the split events out through the remaining filters.")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("worker never sent the split events to the outputs.")
+		}
+	}
+
+	err = worker.Stop()
+	if err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+}
+
+func TestWorkerPipelines(t *testing.T) {
+	config := &common.Config{Log: common.NewLogger(common.NoopLogger), Backlog: 1024, FilterTimeout: 10 * time.Second}
+
+	defaults, err := filter.New(filter.JavascriptFilter, config, &common.FilterConfig{Name: "Default Filter", Code: `event.pipeline = "default"`}, nil, nil)
+	if err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+
+	syslog, err := filter.New(filter.JavascriptFilter, config, &common.FilterConfig{Name: "Syslog Filter", Code: `event.pipeline = "syslog"`}, nil, nil)
+	if err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+
+	out := &recorder{events: make(chan *common.Event, 10)}
+	worker := New(config, 0, []input.Input{}, []filter.Filter{defaults}, map[string][]filter.Filter{"Syslog Input": {syslog}}, []output.Output{out})
+
+	worker.Start()
+	worker.incoming <- &common.Event{Timestamp: time.Now(), Input: "Syslog Input", Data: map[string]interface{}{"message": "woot"}}
+	worker.incoming <- &common.Event{Timestamp: time.Now(), Input: "Http Input", Data: map[string]interface{}{"message": "woot"}}
+
+	for _, expected := range []string{"syslog", "default"} {
+		select {
+		case event := <-out.events:
+			if event.Data["pipeline"] != expected {
+				t.Fatal("worker did not filter the event with the pipeline of its input.")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("worker never sent the filtered events to the outputs.")
+		}
+	}
+
+	err = worker.Stop()
+	if err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+}
+
+func TestWorkerMetadata(t *testing.T) {
+	config := &common.Config{Log: common.NewLogger(common.NoopLogger), Backlog: 1024}
+
+	in, err := input.New(input.NoopInput, config, nil)
+	if err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+
+	filt, err := filter.New(filter.NoopFilter, config, nil, nil, nil)
+	if err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+
+	out := &recorder{events: make(chan *common.Event, 10)}
+	worker := New(config, 7, []input.Input{in}, []filter.Filter{filt}, nil, []output.Output{out})
+
+	worker.Start()
+
+	select {
+	case event := <-out.events:
+		if event.Worker != 7 || event.Received.IsZero() {
+			t.Fatal("worker did not set the worker id and received time on the event.")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("worker never sent the event to the outputs.")
+	}
+
+	go func() {
+		for range out.events {
+		}
+	}()
+
+	err = worker.Stop()
+	if err != nil {
+		t.Fatal("Something is very very wrong.")
+	}
+}